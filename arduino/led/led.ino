@@ -31,21 +31,29 @@ void loop() {
           Serial.write(buf[0]);
 
           int valid = 0;
+          int segment = 0;
           CRGB clr;
 
-          if (buf[0] == 0xAA && buf[1] == 0xBB && buf[2] == 0x00 && buf[6] == 0xCC && buf[7] == 0xDD ) {
+          // The byte before the colour says which part of the strip it's for,
+          // 0 is the whole strip, 1 the first half and 2 the second half
+          if (buf[0] == 0xAA && buf[1] == 0xBB && buf[2] <= 0x02 && buf[6] == 0xCC && buf[7] == 0xDD ) {
+            segment = buf[2];
             clr = CRGB(buf[3], buf[4], buf[5]);
             valid = 1;
-          } else if (buf[0] == 0xBB && buf[1] == 0x00 && buf[5] == 0xCC && buf[6] == 0xDD ) {
+          } else if (buf[0] == 0xBB && buf[1] <= 0x02 && buf[5] == 0xCC && buf[6] == 0xDD ) {
+            segment = buf[1];
             clr = CRGB(buf[2], buf[3], buf[4]);
             valid = 1;
-          } else if (buf[0] == 0x00 && buf[4] == 0xCC && buf[5] == 0xDD ) {
+          } else if (buf[0] <= 0x02 && buf[4] == 0xCC && buf[5] == 0xDD ) {
+            segment = buf[0];
             clr = CRGB(buf[1], buf[2], buf[3]);
             valid = 1;
           }
           
           if (valid) {
-            for( int i = 0; i < NUM_LEDS; i++) {
+            int start = segment == 2 ? NUM_LEDS / 2 : 0;
+            int end = segment == 1 ? NUM_LEDS / 2 : NUM_LEDS;
+            for( int i = start; i < end; i++) {
               leds[i] = clr;
             }
             FastLED.show();  
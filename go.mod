@@ -8,6 +8,7 @@ require (
 	github.com/gen2brain/malgo v0.10.35
 	github.com/lucasb-eyer/go-colorful v1.2.0
 	github.com/rs/zerolog v1.21.0
+	github.com/stretchr/testify v1.7.0
 	go.bug.st/serial v1.3.3
 	golang.org/x/exp v0.0.0-20210722180016-6781d3edade3
 )
@@ -0,0 +1,21 @@
+package dsp
+
+// Autocorrelate returns the autocorrelation of x for each lag from 0 up to
+// and including maxLag. Each value is scaled by len(x)/(len(x)-lag) so that
+// longer lags, which have fewer overlapping samples, aren't penalised.
+// The returned slice is appended to dst.
+func Autocorrelate(dst, x []float64, maxLag int) []float64 {
+	n := len(x)
+	if maxLag >= n {
+		maxLag = n - 1
+	}
+
+	for lag := 0; lag <= maxLag; lag++ {
+		var sum float64
+		for i := 0; i+lag < n; i++ {
+			sum += x[i] * x[i+lag]
+		}
+		dst = append(dst, sum*float64(n)/float64(n-lag))
+	}
+	return dst
+}
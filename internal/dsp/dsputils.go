@@ -30,6 +30,19 @@ func ToComplex(x []float32) []complex64 {
 	return y
 }
 
+// ToComplexPadded returns the complex equivalent of the real-valued slice
+// with zeros appended to the end to the specified length. The returned
+// slice is appended to dst.
+func ToComplexPadded(dst []complex64, x []float32, length int) []complex64 {
+	for _, v := range x {
+		dst = append(dst, complex(v, 0))
+	}
+	for i := len(x); i < length; i++ {
+		dst = append(dst, 0)
+	}
+	return dst
+}
+
 // IsPowerOf2 returns true if x is a power of 2, else false.
 func IsPowerOf2(x int) bool {
 	return x&(x-1) == 0
@@ -0,0 +1,102 @@
+package dsp
+
+// Median returns the median of x, which is sorted in place.
+func Median(x []float64) float64 {
+	// Insertion sort is quick for the short windows medians are taken over
+	for i := 1; i < len(x); i++ {
+		for j := i; j > 0 && x[j] < x[j-1]; j-- {
+			x[j], x[j-1] = x[j-1], x[j]
+		}
+	}
+
+	n := len(x)
+	if n == 0 {
+		return 0
+	}
+	if n%2 == 1 {
+		return x[n/2]
+	}
+	return (x[n/2-1] + x[n/2]) / 2
+}
+
+// MedianFilter returns the median of the window of length n centred on
+// each value of x, the window is shortened where it overlaps the ends.
+// The returned slice is appended to dst.
+func MedianFilter(dst, x []float64, n int) []float64 {
+	window := make([]float64, 0, n)
+	for i := range x {
+		lo, hi := i-n/2, i+n/2+1
+		if lo < 0 {
+			lo = 0
+		}
+		if hi > len(x) {
+			hi = len(x)
+		}
+		window = append(window[:0], x[lo:hi]...)
+		dst = append(dst, Median(window))
+	}
+	return dst
+}
+
+// HPSS separates a stream of magnitude spectra into harmonic and percussive
+// parts as described by Fitzgerald in "Harmonic/Percussive Separation using
+// Median Filtering". Harmonics are steady in time so they survive a median
+// filter across the recent frames of each frequency, while percussion is
+// broadband so it survives a median filter across the frequencies of each
+// frame. Only past frames are used so it can separate audio as it arrives.
+type HPSS struct {
+	frames  int         // How many frames the harmonic median is taken over
+	bins    int         // How many bins the percussive median is taken over
+	history [][]float64 // The recent frames, used as a ring
+	next    int         // Index of history the next frame is written to
+	filled  int         // How many frames of history have been written
+	scratch []float64   // Values the medians are taken over
+	medians []float64   // Percussive medians of the current frame
+}
+
+// NewHPSS returns an HPSS which takes the harmonic median over the given
+// number of frames and the percussive median over the given number of bins.
+func NewHPSS(frames, bins int) *HPSS {
+	if frames < 1 {
+		frames = 1
+	}
+	return &HPSS{frames: frames, bins: bins}
+}
+
+// Separate adds the magnitudes of the next frame and returns its harmonic
+// and percussive parts, which are appended to harmonic and percussive.
+// The parts are separated by soft masks so they add up to the magnitudes.
+func (h *HPSS) Separate(harmonic, percussive, magnitudes []float64) ([]float64, []float64) {
+	// Frames of a different size can't be compared
+	if len(h.history) > 0 && len(h.history[0]) != len(magnitudes) {
+		h.history, h.next, h.filled = nil, 0, 0
+	}
+	if len(h.history) < h.frames {
+		h.history = append(h.history, make([]float64, len(magnitudes)))
+	}
+	copy(h.history[h.next], magnitudes)
+	h.next = (h.next + 1) % h.frames
+	if h.filled < h.frames {
+		h.filled++
+	}
+
+	h.medians = MedianFilter(h.medians[:0], magnitudes, h.bins)
+	for k, m := range magnitudes {
+		h.scratch = h.scratch[:0]
+		for _, frame := range h.history[:h.filled] {
+			h.scratch = append(h.scratch, frame[k])
+		}
+		hm, pm := Median(h.scratch), h.medians[k]
+
+		// Wiener masks share each magnitude by the power of the medians
+		var mask float64
+		if total := hm*hm + pm*pm; total > 0 {
+			mask = hm * hm / total
+		} else {
+			mask = 0.5
+		}
+		harmonic = append(harmonic, m*mask)
+		percussive = append(percussive, m*(1-mask))
+	}
+	return harmonic, percussive
+}
@@ -0,0 +1,28 @@
+package dsp
+
+// Resample returns x, sampled at the rate from, resampled to the rate to
+// using linear interpolation. The returned slice is appended to dst.
+func Resample(dst, x []float32, from, to int) []float32 {
+	if len(x) == 0 || from <= 0 || to <= 0 {
+		return dst
+	}
+	if from == to {
+		return append(dst, x...)
+	}
+
+	length := len(x) * to / from
+	step := float64(from) / float64(to)
+	for i := 0; i < length; i++ {
+		pos := float64(i) * step
+		j := int(pos)
+		if j >= len(x)-1 {
+			dst = append(dst, x[len(x)-1])
+			continue
+		}
+
+		frac := float32(pos - float64(j))
+		dst = append(dst, x[j]+(x[j+1]-x[j])*frac)
+	}
+
+	return dst
+}
@@ -0,0 +1,14 @@
+package dsp
+
+import "math"
+
+// HzToMel converts the frequency f in Hz to the Mel scale.
+func HzToMel(f float64) float64 {
+	return 2595 * math.Log10(1+f/700)
+}
+
+// HzToBark converts the frequency f in Hz to the Bark scale
+// using Traunmüller's formula.
+func HzToBark(f float64) float64 {
+	return 26.81*f/(1960+f) - 0.53
+}
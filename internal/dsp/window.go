@@ -0,0 +1,59 @@
+package dsp
+
+import "math"
+
+// Rectangular returns the coefficients of a rectangular window of length n,
+// which leaves the samples unchanged. The returned slice is appended to dst.
+func Rectangular(dst []float32, n int) []float32 {
+	for i := 0; i < n; i++ {
+		dst = append(dst, 1)
+	}
+	return dst
+}
+
+// Hann returns the coefficients of a Hann window of length n.
+// The returned slice is appended to dst.
+func Hann(dst []float32, n int) []float32 {
+	return cosineSum(dst, n, 0.5, 0.5)
+}
+
+// Hamming returns the coefficients of a Hamming window of length n.
+// The returned slice is appended to dst.
+func Hamming(dst []float32, n int) []float32 {
+	return cosineSum(dst, n, 0.54, 0.46)
+}
+
+// BlackmanHarris returns the coefficients of a four term Blackman-Harris
+// window of length n. The returned slice is appended to dst.
+func BlackmanHarris(dst []float32, n int) []float32 {
+	return cosineSum(dst, n, 0.35875, 0.48829, 0.14128, 0.01168)
+}
+
+// FlatTop returns the coefficients of a flat-top window of length n.
+// The returned slice is appended to dst.
+func FlatTop(dst []float32, n int) []float32 {
+	return cosineSum(dst, n, 0.21557895, 0.41663158, 0.277263158, 0.083578947, 0.006947368)
+}
+
+// cosineSum returns the coefficients of the window of length n described by
+// the sum of cosines with the amplitudes a. The window is periodic rather
+// than symmetric because it's used for spectral analysis.
+func cosineSum(dst []float32, n int, a ...float64) []float32 {
+	for i := 0; i < n; i++ {
+		var w, sign float64 = 0, 1
+		for k, ak := range a {
+			w += sign * ak * math.Cos(2*math.Pi*float64(k)*float64(i)/float64(n))
+			sign = -sign
+		}
+		dst = append(dst, float32(w))
+	}
+	return dst
+}
+
+// ApplyWindow multiplies each sample in x by the coefficient at the same
+// index in w, len(w) must be at least len(x).
+func ApplyWindow(x, w []float32) {
+	for i := range x {
+		x[i] *= w[i]
+	}
+}
@@ -0,0 +1,59 @@
+package dsp
+
+// YIN estimates the fundamental frequency of x, sampled at sampleRate, with
+// the YIN algorithm. Periods up to half the length of x are considered, so
+// x must hold at least two periods of the lowest frequency to be detected.
+// threshold, usually 0.1 to 0.2, is the highest aperiodicity accepted as a
+// pitch. It returns zero if x isn't periodic enough to have a pitch.
+// The differences are calculated in scratch, which is grown if it's too
+// short and returned so it can be reused.
+func YIN(scratch []float64, x []float32, sampleRate, threshold float64) (float64, []float64) {
+	maxLag := len(x) / 2
+	if maxLag < 3 {
+		return 0, scratch
+	}
+	width := len(x) - maxLag
+
+	// The cumulative mean normalised difference of x with itself at each lag
+	if cap(scratch) < maxLag {
+		scratch = make([]float64, maxLag)
+	}
+	d := scratch[:maxLag]
+	d[0] = 1
+	var sum float64
+	for lag := 1; lag < maxLag; lag++ {
+		var diff float64
+		for j := 0; j < width; j++ {
+			delta := float64(x[j]) - float64(x[j+lag])
+			diff += delta * delta
+		}
+		sum += diff
+		if sum == 0 {
+			d[lag] = 1
+		} else {
+			d[lag] = diff * float64(lag) / sum
+		}
+	}
+
+	// Take the first dip below the threshold, at its lowest point
+	lag := 0
+	for l := 2; l < maxLag-1; l++ {
+		if d[l] < threshold {
+			for l+1 < maxLag-1 && d[l+1] < d[l] {
+				l++
+			}
+			lag = l
+			break
+		}
+	}
+	if lag == 0 {
+		return 0, scratch
+	}
+
+	// Interpolate between lags with a parabola through the dip
+	period := float64(lag)
+	if l, c, r := d[lag-1], d[lag], d[lag+1]; l-2*c+r != 0 {
+		period += 0.5 * (l - r) / (l - 2*c + r)
+	}
+	return sampleRate / period, scratch
+}
@@ -86,6 +86,45 @@ func FFT(x []complex64) []complex64 {
 	return bluesteinFFT(x)
 }
 
+// FFTTo returns the forward FFT of x, which is written to dst if it's
+// long enough. Power of 2 lengths are transformed in place in dst without
+// allocating, or starting any workers, while other lengths fall back to FFT.
+func FFTTo(dst, x []complex64) []complex64 {
+	lx := len(x)
+	if lx <= 1 || !dsp.IsPowerOf2(lx) {
+		return append(dst[:0], FFT(x)...)
+	}
+
+	if cap(dst) < lx {
+		dst = make([]complex64, lx)
+	}
+	dst = dst[:lx]
+	factors := getRadix2Factors(lx)
+
+	s := log2(uint(lx))
+	for n, v := range x {
+		dst[reverseBits(uint(n), s)] = v
+	}
+
+	for stage := 2; stage <= lx; stage <<= 1 {
+		blocks, s_2 := lx/stage, stage/2
+		for nb := 0; nb < lx; nb += stage {
+			for j := 0; j < s_2; j++ {
+				idx := j + nb
+				idx2 := idx + s_2
+				// The first factor is always 1, and there are none for length 2
+				w_n := dst[idx2]
+				if j > 0 {
+					w_n *= factors[blocks*j]
+				}
+				dst[idx], dst[idx2] = dst[idx]+w_n, dst[idx]-w_n
+			}
+		}
+	}
+
+	return dst
+}
+
 var (
 	worker_pool_size = 0
 )
@@ -0,0 +1,83 @@
+package audio
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// The normalised range is never narrower than this many dB,
+	// so steady audio isn't stretched over the whole range
+	agcMinRange = 12
+	// The tracked peak never falls below this many dBFS, so
+	// silence isn't amplified until it fills the range
+	agcMinPeak = -70
+)
+
+// AGC describes how quickly the automatic gain control adapts to the level
+// of the audio. It tracks the recent peak and floor of each feature so
+// they can be normalised into 0..1 regardless of how loud the source is
+type AGC struct {
+	// How quickly the range expands to include louder or quieter audio
+	Attack time.Duration
+	// How quickly the range shrinks back once the audio settles
+	Release time.Duration
+}
+
+// DefaultAGC returns an AGC which reacts to peaks quickly
+// and forgets them over a few seconds
+func DefaultAGC() AGC {
+	return AGC{
+		Attack:  50 * time.Millisecond,
+		Release: 5 * time.Second,
+	}
+}
+
+// follower tracks the recent peak and floor of a feature in dB
+type follower struct {
+	peak   float64
+	floor  float64
+	primed bool // Whether the follower has seen a value
+}
+
+// normalise updates the range with x, which lasted for dt, and returns
+// where x lies in the range from 0 to 1
+func (fl *follower) normalise(x float64, dt time.Duration, agc AGC) float64 {
+	if !fl.primed {
+		fl.peak, fl.floor, fl.primed = x, x, true
+	}
+
+	attack := smoothing(dt, agc.Attack)
+	release := smoothing(dt, agc.Release)
+	if x > fl.peak {
+		fl.peak += attack * (x - fl.peak)
+	} else {
+		fl.peak += release * (x - fl.peak)
+	}
+	if x < fl.floor {
+		fl.floor += attack * (x - fl.floor)
+	} else {
+		fl.floor += release * (x - fl.floor)
+	}
+	fl.peak = math.Max(fl.peak, agcMinPeak)
+
+	floor := math.Min(fl.floor, fl.peak-agcMinRange)
+	return math.Max(0, math.Min(1, (x-floor)/(fl.peak-floor)))
+}
+
+// smoothing returns how much of the way a value moves towards its target
+// in dt when it moves exponentially with the time constant tau
+func smoothing(dt, tau time.Duration) float64 {
+	if tau <= 0 {
+		return 1
+	}
+	return 1 - math.Exp(-float64(dt)/float64(tau))
+}
+
+// decibels converts the power to dB, silence is clamped to silentLoudness
+func decibels(power float64) float64 {
+	if power <= 0 {
+		return silentLoudness
+	}
+	return math.Max(silentLoudness, 10*math.Log10(power))
+}
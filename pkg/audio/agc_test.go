@@ -0,0 +1,58 @@
+package audio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFollower(t *testing.T) {
+	agc := DefaultAGC()
+	dt := 10 * time.Millisecond
+	var fl follower
+	feed := func(x float64, d time.Duration) (level float64) {
+		for i := time.Duration(0); i < d; i += dt {
+			level = fl.normalise(x, dt, agc)
+		}
+		return level
+	}
+
+	// Steady audio is at the top of the range
+	assert.InDelta(t, 1, feed(-40, time.Second), 1e-6)
+
+	// Quieter audio is at the bottom until the range adapts to it
+	assert.InDelta(t, 0, fl.normalise(-60, dt, agc), 0.1)
+	assert.InDelta(t, 1, feed(-60, 30*time.Second), 0.01)
+
+	// Louder audio reaches the top almost immediately
+	assert.InDelta(t, 1, feed(-20, 200*time.Millisecond), 0.05)
+
+	// Silence is never amplified to fill the range
+	assert.InDelta(t, 0, feed(silentLoudness, 30*time.Second), 1e-6)
+}
+
+func TestAGCLoudness(t *testing.T) {
+	// Loud and quiet sources have the same normalised loudness
+	for _, gain := range []float64{1, 0.01} {
+		f, err := NewFFT(&Config{Channels: 1, SampleRate: 44100, Format: F32})
+		assert.Nil(t, err)
+
+		samples := sine(440, 1, 44100, 2*time.Second)
+		for i := range samples {
+			samples[i] *= gain
+		}
+		f.Write(encodeF32(samples))
+
+		var a analysis
+		for f.fill(&a) {
+			f.spectrum(&a)
+			f.onset(&a)
+			f.loudness(&a)
+		}
+		assert.InDelta(t, 1, a.loudnessLevel, 0.01)
+
+		f.Dynamics.Adaptive = true
+		assert.InDelta(t, 1, f.level(&a), 0.01)
+	}
+}
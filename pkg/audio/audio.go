@@ -1,6 +1,7 @@
 package audio
 
 import (
+	"context"
 	"errors"
 	"io"
 	"sync"
@@ -8,20 +9,21 @@ import (
 	"github.com/gen2brain/malgo"
 )
 
-var errDeviceChange = errors.New("the device has changed") // Signifies the capture func should not exit
-var sampleSizeInBytes = malgo.SampleSizeInBytes(malgo.FormatF32)
+var (
+	ErrDestroyed = errors.New("audio context has been destroyed")
+	ErrNoContext = errors.New("audio has no context to capture devices with")
+)
 
 type Audio struct {
 	*sync.Mutex
 
 	// Malgo context
-	context *malgo.AllocatedContext
+	context   *malgo.AllocatedContext
+	destroyed bool
 
 	// Capture context
-	Done        chan error
-	initialised bool
-	device      *malgo.Device
-	abortChan   chan error
+	cancel   context.CancelFunc // Stops the source currently being streamed, nil if stopped
+	finished chan struct{}      // Closed once the current source has stopped
 }
 
 // Creation / Deletion ---------------------------------------------
@@ -32,16 +34,17 @@ func NewAudio() (*Audio, error) {
 	if err != nil {
 		return nil, err
 	}
+	return newAudio(c), nil
+}
 
-	a := &Audio{
-		Mutex:     &sync.Mutex{},
-		context:   c,
-		abortChan: make(chan error),
-		Done:      make(chan error),
+// newAudio creates audio which uses the context c. Without a context it
+// can still stream sources other than capture devices, which report
+// ErrNoContext instead
+func newAudio(c *malgo.AllocatedContext) *Audio {
+	return &Audio{
+		Mutex:   &sync.Mutex{},
+		context: c,
 	}
-	go a.drainDoneChan()
-
-	return a, nil
 }
 
 // MustCreateNewAudio panics if the audio was created unsuccessfully
@@ -53,11 +56,22 @@ func MustCreateNewAudio() *Audio {
 	return a
 }
 
-// Destroy un-initialises the context and then frees it
+// Destroy stops the current source, un-initialises the context and
+// then frees it. Destroying the audio more than once is a no-op
 func (a *Audio) Destroy() error {
 	a.Lock()
-	defer a.Unlock()
+	if a.destroyed {
+		a.Unlock()
+		return nil
+	}
+	a.destroyed = true
+	a.Unlock()
 
+	a.Stop()
+
+	if a.context == nil {
+		return nil
+	}
 	err := a.context.Uninit()
 	if err != nil {
 		return err
@@ -73,6 +87,12 @@ func (a *Audio) Devices() ([]Device, error) {
 	a.Lock()
 	defer a.Unlock()
 
+	if a.destroyed {
+		return nil, ErrDestroyed
+	} else if a.context == nil {
+		return nil, ErrNoContext
+	}
+
 	infos, err := a.context.Devices(malgo.Capture)
 	if err != nil {
 		return nil, err
@@ -100,66 +120,108 @@ func (a *Audio) MustParseDevices() []Device {
 
 // Capture State ---------------------------------------------
 
-func (a *Audio) StartCapture(d Device, w io.Writer, conf *Config) error {
-	a.stopCapture(errDeviceChange)
-
-	a.Lock()
-	defer a.Unlock()
-
-	// Create channels to abort the process
-	aborted := false
-
-	// Writes the data to the writer
-	deviceConfig := d.config(conf)
-	deviceCallbacks := malgo.DeviceCallbacks{
-		Data: func(outputSamples, inputSamples []byte, frameCount uint32) {
-			if aborted {
-				return
-			}
+// Run streams the frames from src to w until src finishes or ctx is
+// cancelled. Any source which is already running is stopped first, so
+// calling Run again is how the source is changed. If w is a FormatWriter
+// it's told the source's format before src starts, and if it's a
+// StopWriter it's told once src has been stopped.
+//
+// Run returns nil if src was exhausted, the error src failed with, or
+// ctx.Err() if it was stopped, which includes being replaced by another
+// call to Run or Stop being called
+func (a *Audio) Run(ctx context.Context, src Source, w io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	finished, err := a.claim(ctx, cancel)
+	if err != nil {
+		return err
+	}
+	defer a.release(finished)
 
-			_, err := w.Write(inputSamples)
-			if err != nil {
-				aborted = true
-				a.abortChan <- err
-			}
-		},
+	if fw, ok := w.(FormatWriter); ok {
+		if err := fw.SetFormat(src.Format()); err != nil {
+			return err
+		}
 	}
 
-	// Stream the data to the writer
-	device, err := malgo.InitDevice(a.context.Context, deviceConfig, deviceCallbacks)
+	// Sources may report they're done after they've been stopped
+	// so this must never block
+	done := make(chan error, 1)
+	err = src.Start(w, func(err error) {
+		select {
+		case done <- err:
+		default:
+		}
+	})
 	if err != nil {
 		return err
 	}
-	a.initialised = true
-	a.device = device
+	defer src.Stop()
 
-	err = device.Start()
-	if err != nil {
+	select {
+	case err := <-done:
 		return err
+	case <-ctx.Done():
+		// The writer is only told once nothing more will be written
+		src.Stop()
+		if sw, ok := w.(StopWriter); ok {
+			sw.Stopped()
+		}
+		return ctx.Err()
 	}
-
-	return nil
 }
 
-func (a *Audio) StopCapture() {
-	a.stopCapture(nil)
-}
-
-func (a *Audio) stopCapture(err error) {
+// Stop stops the current source and waits until it has stopped.
+// It's safe to call at any time, including after Destroy
+func (a *Audio) Stop() {
 	a.Lock()
-	if a.initialised {
-		a.device.Uninit()
-	}
-	a.initialised = false
+	cancel, finished := a.cancel, a.finished
 	a.Unlock()
-	a.abortChan <- err
+
+	if cancel != nil {
+		cancel()
+		<-finished
+	}
 }
 
-func (a *Audio) drainDoneChan() {
-	for e := range a.abortChan {
-		if e == errDeviceChange {
-			continue
+// claim stops the current source and registers cancel as the
+// way to stop the new one, it returns a channel which must be
+// closed by release once the new source has stopped. If ctx is
+// cancelled first then the current source is left running
+func (a *Audio) claim(ctx context.Context, cancel context.CancelFunc) (chan struct{}, error) {
+	a.Lock()
+	defer a.Unlock()
+
+	// Another call to Run may claim the source while we
+	// wait for the current one to stop, so we keep trying
+	for a.cancel != nil {
+		if err := ctx.Err(); err != nil {
+			return nil, err
 		}
-		go func(e error) { a.Done <- e }(e)
+
+		stop, finished := a.cancel, a.finished
+		a.Unlock()
+		stop()
+		<-finished
+		a.Lock()
+	}
+
+	if a.destroyed {
+		return nil, ErrDestroyed
+	} else if err := ctx.Err(); err != nil {
+		return nil, err
 	}
+
+	a.cancel = cancel
+	a.finished = make(chan struct{})
+	return a.finished, nil
+}
+
+func (a *Audio) release(finished chan struct{}) {
+	a.Lock()
+	a.cancel = nil
+	a.finished = nil
+	a.Unlock()
+	close(finished)
 }
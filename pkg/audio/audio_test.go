@@ -0,0 +1,142 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+// stopRecorder discards what's written to it and records whether it was stopped
+type stopRecorder struct {
+	stopped bool
+}
+
+func (*stopRecorder) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (sr *stopRecorder) Stopped() {
+	sr.stopped = true
+}
+
+func newTestWAV(t *testing.T, d time.Duration) *WAVSource {
+	ws, err := NewWAVSource(bytes.NewReader(encodeWAV(sine(440, 2, 44100, d), 2, 44100, 16, false)))
+	assert.Nil(t, err)
+	ws.RealTime = true
+	return ws
+}
+
+func TestAudioLifecycle(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	// Only the lifecycle is being tested so there's no need for a malgo context
+	a := newAudio(nil)
+
+	// The source finishing on its own isn't an error
+	assert.Nil(t, a.Run(context.Background(), newTestWAV(t, 50*time.Millisecond), ioutil.Discard))
+
+	// The source failing returns its error
+	assert.EqualError(t, a.Run(context.Background(), newTestWAV(t, time.Second), failingWriter{}), "write failed")
+
+	// Cancelling the context stops the source
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, a.Run(ctx, newTestWAV(t, time.Second), ioutil.Discard))
+
+	// Sources can be started and stopped repeatedly, starting
+	// a new source stops the one which is already running
+	errs := make(chan error, 1)
+	for i := 0; i < 5; i++ {
+		ws := newTestWAV(t, time.Second)
+		go func() { errs <- a.Run(context.Background(), ws, ioutil.Discard) }()
+		for ws.Frames() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		if i > 0 {
+			assert.Equal(t, context.Canceled, <-errs)
+		}
+	}
+	a.Stop()
+	a.Stop()
+	assert.Equal(t, context.Canceled, <-errs)
+
+	// Stopping or destroying after the audio is destroyed is a no-op
+	assert.Nil(t, a.Destroy())
+	assert.Nil(t, a.Destroy())
+	a.Stop()
+	assert.Equal(t, ErrDestroyed, a.Run(context.Background(), newTestWAV(t, time.Second), ioutil.Discard))
+	_, err := a.Devices()
+	assert.Equal(t, ErrDestroyed, err)
+
+	assertNoLeaks(t, before)
+}
+
+func TestAudioNoContext(t *testing.T) {
+	a := newAudio(nil)
+	_, err := a.Devices()
+	assert.Equal(t, ErrNoContext, err)
+	cs := a.NewCaptureSource(Device{}, DefaultConfig())
+	assert.Equal(t, ErrNoContext, cs.Start(ioutil.Discard, func(error) {}))
+	assert.Nil(t, a.Destroy())
+}
+
+func TestAudioStopped(t *testing.T) {
+	a := newAudio(nil)
+
+	// A source which runs out is left to fade
+	var sr stopRecorder
+	assert.Nil(t, a.Run(context.Background(), newTestWAV(t, 50*time.Millisecond), &sr))
+	assert.False(t, sr.stopped)
+
+	// But the writer is told when one is stopped deliberately
+	ws := newTestWAV(t, time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, a.Run(ctx, ws, &sr))
+	assert.True(t, sr.stopped)
+}
+
+func TestAudioFFT(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	// Sources which aren't capture devices don't need a malgo context
+	a := newAudio(nil)
+	f, err := NewFFT(DefaultConfig())
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	fftErrs := make(chan error, 1)
+	go func() { fftErrs <- f.Run(ctx) }()
+
+	// The FFT is told the format of each source
+	ws, err := NewWAVSource(bytes.NewReader(encodeWAV(sine(440, 1, 48000, time.Second), 1, 48000, 24, false)))
+	assert.Nil(t, err)
+	ws.RealTime = true
+	audioErrs := make(chan error, 1)
+	go func() { audioErrs <- a.Run(ctx, ws, f) }()
+
+	select {
+	case <-f.Hues:
+	case <-time.After(time.Second):
+		t.Fatal("no colours were produced")
+	}
+	assert.Equal(t, ws.Format(), *f.config())
+
+	cancel()
+	assert.Equal(t, context.Canceled, <-audioErrs)
+	assert.Equal(t, context.Canceled, <-fftErrs)
+
+	assertNoLeaks(t, before)
+}
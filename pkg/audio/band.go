@@ -0,0 +1,61 @@
+package audio
+
+// Band is a range of frequencies whose energy is measured
+type Band struct {
+	Name string
+	// Lowest frequency in the band in Hz, inclusive
+	Low float64
+	// Highest frequency in the band in Hz, exclusive
+	High float64
+}
+
+// BandEnergy is the energy of the audio in a band
+type BandEnergy struct {
+	Band
+	// Mean power of the audio in the band, a full scale
+	// sine wave in the band has a power of 0.5
+	Energy float64
+	// Energy in dB normalised by the AGC into 0..1 according
+	// to the range the band has recently been in
+	Level float64
+}
+
+// DefaultBands returns the bands which are usually used to describe music
+func DefaultBands() []Band {
+	return []Band{
+		{Name: "Sub-bass", Low: 20, High: 60},
+		{Name: "Bass", Low: 60, High: 250},
+		{Name: "Low-mid", Low: 250, High: 500},
+		{Name: "Mid", Low: 500, High: 2000},
+		{Name: "High", Low: 2000, High: 20000},
+	}
+}
+
+// energies calculates the energy in each band from the spectrum,
+// they're kept so they can be routed to the colour
+func (f *FFT) energies(a *analysis) []BandEnergy {
+	bands := f.Bands
+	if len(a.bandAGC) != len(bands) {
+		a.bandAGC = make([]follower, len(bands))
+	}
+
+	e := make([]BandEnergy, len(bands))
+	for i, b := range bands {
+		e[i].Band = b
+
+		var sum float64
+		for k, m := range a.magnitudes {
+			if freq := float64(k) * a.binSize; freq >= b.Low && freq < b.High {
+				sum += m * m
+			}
+		}
+
+		// By Parseval's theorem this is the mean power of the frame
+		// in the band, it's doubled because only half the spectrum
+		// is summed and normalised by the power of the window
+		e[i].Energy = 2 * sum / (float64(a.fftSize) * a.windowPower)
+		e[i].Level = a.bandAGC[i].normalise(decibels(e[i].Energy), a.hopDuration, f.AGC)
+	}
+	a.energies = e
+	return e
+}
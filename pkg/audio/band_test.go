@@ -0,0 +1,64 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// encodeF32 encodes the samples in the F32 format
+func encodeF32(samples []float64) []byte {
+	buf := bytes.NewBuffer(nil)
+	for _, s := range samples {
+		binary.Write(buf, binary.LittleEndian, float32(s))
+	}
+	return buf.Bytes()
+}
+
+// analyseSamples returns the band energies of the first buffer of samples
+func analyseSamples(t *testing.T, f *FFT, samples []float64) []BandEnergy {
+	f.Write(encodeF32(samples))
+
+	var a analysis
+	assert.True(t, f.fill(&a))
+	f.spectrum(&a)
+	return f.energies(&a)
+}
+
+func TestBandEnergies(t *testing.T) {
+	tests := []struct {
+		freq float64
+		band string
+	}{
+		{40, "Sub-bass"},
+		{100, "Bass"},
+		{400, "Low-mid"},
+		{1000, "Mid"},
+		{5000, "High"},
+	}
+
+	for _, tt := range tests {
+		f, err := NewFFT(&Config{Channels: 1, SampleRate: 44100, Format: F32})
+		assert.Nil(t, err)
+		f.FrameSize = 8192
+
+		// Nearly all of the sine's power, 0.125, is in its band
+		for _, e := range analyseSamples(t, f, sine(tt.freq, 1, 44100, time.Second)) {
+			if e.Name == tt.band {
+				assert.InDelta(t, 0.125, e.Energy, 0.01, "%vHz in %s", tt.freq, e.Name)
+			} else {
+				assert.Less(t, e.Energy, 0.001, "%vHz in %s", tt.freq, e.Name)
+			}
+		}
+	}
+
+	// Silence has no energy
+	f, err := NewFFT(&Config{Channels: 1, SampleRate: 44100, Format: F32})
+	assert.Nil(t, err)
+	for _, e := range analyseSamples(t, f, make([]float64, 2048)) {
+		assert.Equal(t, 0.0, e.Energy)
+	}
+}
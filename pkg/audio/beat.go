@@ -0,0 +1,151 @@
+package audio
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+var ErrInvalidBeatMode = errors.New("beat mode specified is invalid")
+
+const (
+	// How many buffers the mean spectral flux is taken over
+	fluxHistory = 32
+	// Flux below this is never considered an onset, so the noise
+	// floor of quiet audio doesn't cause beats
+	minFlux = 0.05
+	// Onsets closer together than this are treated as one beat
+	minBeatInterval = 100 * time.Millisecond
+	// How long a flash takes to fade back to the normal colour
+	flashDuration = 150 * time.Millisecond
+	// How many hues are stepped through when there's no gradient
+	beatSteps = 8
+	// How many beats it takes to cycle through the gradient
+	cycleBeats = 16
+	// Tempo which the gradient is cycled at if the tempo is unknown
+	defaultBPM = 120
+)
+
+// Beat is an onset detected in the audio, such as a drum hit
+type Beat struct {
+	// When the beat was detected
+	Time time.Time
+	// How many times larger the spectral flux was than the threshold
+	Strength float64
+}
+
+// BeatMode controls how beats change the colour
+type BeatMode int
+
+const (
+	// FollowFrequency ignores beats so the colour only follows the frequency
+	FollowFrequency BeatMode = iota
+	// StepGradient moves to the next colour in the gradient on each beat
+	StepGradient
+	// Flash flashes white on each beat before fading back to the colour
+	Flash
+	// CycleGradient slowly cycles through the gradient in time with the tempo
+	CycleGradient
+)
+
+// BeatModes lists every beat mode in the order they're declared
+var BeatModes = []BeatMode{FollowFrequency, StepGradient, Flash, CycleGradient}
+
+func (bm BeatMode) String() string {
+	return [...]string{"Frequency", "Step", "Flash", "Cycle"}[bm]
+}
+
+// onset calculates the spectral flux of the buffer, the increase in the
+// magnitude of each frequency since the previous buffer, and reports
+// whether it's large enough compared to the recent flux to be a beat
+func (f *FFT) onset(a *analysis) (Beat, bool) {
+	conf := f.config()
+	a.hopDuration = time.Duration(a.hop/int(conf.Channels)) * time.Second / time.Duration(conf.SampleRate)
+	a.sinceBeat += a.hopDuration
+
+	if len(a.prevMagnitudes) != len(a.transient) {
+		a.prevMagnitudes = append(a.prevMagnitudes[:0], a.transient...)
+		a.flux = 0
+		return Beat{}, false
+	}
+
+	// Magnitudes are scaled so that a full scale sine wave
+	// has a magnitude of 1 regardless of the frame or window
+	scale := 2 / a.windowSum
+	var flux float64
+	for k, m := range a.transient {
+		if d := (m - a.prevMagnitudes[k]) * scale; d > 0 {
+			flux += d
+		}
+	}
+	copy(a.prevMagnitudes, a.transient)
+	a.flux = flux
+
+	// The threshold adapts to the mean of the recent flux
+	var mean float64
+	for _, x := range a.fluxHistory {
+		mean += x
+	}
+	if len(a.fluxHistory) > 0 {
+		mean /= float64(len(a.fluxHistory))
+	}
+	if len(a.fluxHistory) < fluxHistory {
+		a.fluxHistory = append(a.fluxHistory, flux)
+	} else {
+		a.fluxHistory[a.fluxIndex] = flux
+		a.fluxIndex = (a.fluxIndex + 1) % fluxHistory
+	}
+
+	threshold := mean * f.BeatSensitivity
+	if threshold < minFlux {
+		threshold = minFlux
+	}
+	if flux <= threshold || a.sinceBeat < minBeatInterval {
+		return Beat{}, false
+	}
+
+	a.sinceBeat = 0
+	a.beats++
+	a.lastBeat = time.Now()
+	return Beat{Time: a.lastBeat, Strength: flux / threshold}, true
+}
+
+// beatColour changes the colour c according to the beat mode
+func (f *FFT) beatColour(a *analysis, c colorful.Color) colorful.Color {
+	switch f.BeatMode {
+	case FollowFrequency:
+		return c
+	case StepGradient:
+		if f.Gradient != nil && len(*f.Gradient) > 0 {
+			g := *f.Gradient
+			return g[a.beats%len(g)].Col
+		}
+		return colorful.Hsv(float64(a.beats%beatSteps)*360/beatSteps, 1, 1)
+	case Flash:
+		since := time.Since(a.lastBeat)
+		if since >= flashDuration {
+			return c
+		}
+		t := 1 - float64(since)/float64(flashDuration)
+		return c.BlendRgb(colorful.Color{R: 1, G: 1, B: 1}, t).Clamped()
+	case CycleGradient:
+		bpm := f.Tempo().BPM
+		if bpm == 0 {
+			bpm = defaultBPM
+		}
+		now := time.Now()
+		if !a.cycled.IsZero() {
+			a.phase = math.Mod(a.phase+now.Sub(a.cycled).Minutes()*bpm/cycleBeats, 1)
+		}
+		a.cycled = now
+
+		if f.Gradient != nil {
+			return f.DrawMode.Interpolate(a.phase, *f.Gradient)
+		}
+		return colorful.Hsv(a.phase*360, 1, 1)
+	}
+
+	panic(ErrInvalidBeatMode)
+}
@@ -0,0 +1,80 @@
+package audio
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/lucasb-eyer/go-colorful"
+	"github.com/stretchr/testify/assert"
+)
+
+// clicks returns mono audio with a short burst of noise every interval,
+// starting half an interval in
+func clicks(sampleRate int, interval, d time.Duration) []float64 {
+	r := rand.New(rand.NewSource(1))
+	samples := make([]float64, int(d.Seconds()*float64(sampleRate)))
+	every := int(interval.Seconds() * float64(sampleRate))
+	burst := sampleRate / 50
+	for i := range samples {
+		if pos := (i + every/2) % every; pos < burst {
+			decay := math.Exp(-5 * float64(pos) / float64(burst))
+			samples[i] = 0.8 * decay * (r.Float64()*2 - 1)
+		} else {
+			samples[i] = 0.001 * (r.Float64()*2 - 1)
+		}
+	}
+	return samples
+}
+
+// countBeats analyses the samples and returns how many beats were detected
+func countBeats(t *testing.T, f *FFT, samples []float64) int {
+	f.Write(encodeF32(samples))
+
+	var a analysis
+	var beats int
+	for f.fill(&a) {
+		f.spectrum(&a)
+		if _, ok := f.onset(&a); ok {
+			beats++
+		}
+	}
+	return beats
+}
+
+func TestBeatDetection(t *testing.T) {
+	f, err := NewFFT(&Config{Channels: 1, SampleRate: 44100, Format: F32})
+	assert.Nil(t, err)
+	f.HopSize = 256
+	assert.Equal(t, 5, countBeats(t, f, clicks(44100, 500*time.Millisecond, 2500*time.Millisecond)))
+
+	// Steady tones and silence don't have beats
+	f.ring.Reset()
+	assert.Equal(t, 0, countBeats(t, f, sine(440, 1, 44100, 2*time.Second)))
+	assert.Equal(t, 0, countBeats(t, f, make([]float64, 44100)))
+}
+
+func TestBeatColour(t *testing.T) {
+	f, err := NewFFT(DefaultConfig())
+	assert.Nil(t, err)
+	red := colorful.Color{R: 1}
+	var a analysis
+
+	assert.Equal(t, red, f.beatColour(&a, red))
+
+	// Each beat steps to the next colour in the gradient
+	f.BeatMode = StepGradient
+	f.Gradient = &Gradient{{Col: colorful.Color{G: 1}, Pos: 0}, {Col: colorful.Color{B: 1}, Pos: 1}}
+	a.beats = 1
+	assert.Equal(t, colorful.Color{B: 1}, f.beatColour(&a, red))
+	a.beats = 2
+	assert.Equal(t, colorful.Color{G: 1}, f.beatColour(&a, red))
+
+	// Flashes fade back to the colour
+	f.BeatMode = Flash
+	a.lastBeat = time.Now()
+	assert.NotEqual(t, red, f.beatColour(&a, red))
+	a.lastBeat = time.Now().Add(-flashDuration)
+	assert.Equal(t, red, f.beatColour(&a, red))
+}
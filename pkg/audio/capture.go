@@ -0,0 +1,96 @@
+package audio
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gen2brain/malgo"
+)
+
+// CaptureSource is a Source which streams audio from a malgo capture device
+type CaptureSource struct {
+	frames uint64 // Frames written, must be accessed atomically and kept first for alignment
+
+	m sync.Mutex
+
+	context *malgo.AllocatedContext
+	device  Device
+	conf    Config
+
+	handle *malgo.Device // Initialised malgo device, nil if not running
+}
+
+// NewCaptureSource creates a capture source for the device using the
+// audio's malgo context, the sample format is negotiated with the device
+func (a *Audio) NewCaptureSource(d Device, conf *Config) *CaptureSource {
+	return &CaptureSource{
+		context: a.context,
+		device:  d,
+		conf:    d.Negotiate(conf),
+	}
+}
+
+func (cs *CaptureSource) Format() Config {
+	return cs.conf
+}
+
+func (cs *CaptureSource) Frames() uint64 {
+	return atomic.LoadUint64(&cs.frames)
+}
+
+func (cs *CaptureSource) Start(w io.Writer, done func(error)) error {
+	cs.m.Lock()
+	defer cs.m.Unlock()
+
+	if cs.context == nil {
+		return ErrNoContext
+	}
+	if cs.handle != nil {
+		cs.handle.Uninit()
+		cs.handle = nil
+	}
+	atomic.StoreUint64(&cs.frames, 0)
+
+	// Writes the data to the writer
+	aborted := false
+	deviceConfig := cs.device.config(&cs.conf)
+	deviceCallbacks := malgo.DeviceCallbacks{
+		Data: func(outputSamples, inputSamples []byte, frameCount uint32) {
+			if aborted {
+				return
+			}
+
+			_, err := w.Write(inputSamples)
+			if err != nil {
+				aborted = true
+				done(err)
+				return
+			}
+			atomic.AddUint64(&cs.frames, uint64(frameCount))
+		},
+	}
+
+	// Stream the data to the writer
+	device, err := malgo.InitDevice(cs.context.Context, deviceConfig, deviceCallbacks)
+	if err != nil {
+		return err
+	}
+	cs.handle = device
+
+	return device.Start()
+}
+
+func (cs *CaptureSource) Stop() error {
+	cs.m.Lock()
+	defer cs.m.Unlock()
+
+	if cs.context == nil {
+		return ErrNoContext
+	}
+	if cs.handle != nil {
+		cs.handle.Uninit()
+		cs.handle = nil
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package audio
+
+import (
+	"errors"
+	"math"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+var ErrInvalidMapping = errors.New("colour mapping specified is invalid")
+
+// Range of frequencies which are included in the chromagram, from A0
+// up to where harmonics dominate more than the notes themselves
+const (
+	minChromaFreq = 27.5
+	maxChromaFreq = 5000
+)
+
+// ColourMapping is how the analysed audio is turned into a colour
+type ColourMapping int
+
+const (
+	// FrequencyRamp maps the frequency onto the hue, or the gradient,
+	// using the FrequencyScale
+	FrequencyRamp ColourMapping = iota
+	// Chroma maps the loudest pitch class onto a colour so the same
+	// note always has the same colour regardless of its octave
+	Chroma
+	// Routed creates the colour from the features of the audio
+	// as described by the Routes
+	Routed
+)
+
+// ColourMappings lists every mapping in the order they're declared
+var ColourMappings = []ColourMapping{FrequencyRamp, Chroma, Routed}
+
+func (cm ColourMapping) String() string {
+	return [...]string{"Frequency", "Chroma", "Routes"}[cm]
+}
+
+// PitchClass is a note regardless of its octave, from C = 0 to B = 11
+type PitchClass int
+
+func (pc PitchClass) String() string {
+	return [...]string{"C", "C#", "D", "D#", "E", "F", "F#", "G", "G#", "A", "A#", "B"}[pc]
+}
+
+// ScriabinPalette returns the colours Scriabin associated with each pitch
+// class, for use as FFT.Palette
+func ScriabinPalette() []colorful.Color {
+	hex := []string{
+		"#ff0000", "#cf9bff", "#ffff00", "#656599", "#e3fbff", "#ac1c02",
+		"#00ccff", "#ff6501", "#ff00ff", "#33cc33", "#8c8a8c", "#0000fe",
+	}
+
+	p := make([]colorful.Color, len(hex))
+	for i, h := range hex {
+		p[i], _ = colorful.Hex(h)
+	}
+	return p
+}
+
+// chroma sums the power of the spectrum in each pitch class and returns
+// the loudest, if the spectrum is silent then the previous class is kept
+func (a *analysis) chroma() PitchClass {
+	a.chromagram = [12]float64{}
+	for k, m := range a.tonal {
+		freq := float64(k) * a.binSize
+		if freq < minChromaFreq || freq > maxChromaFreq {
+			continue
+		}
+
+		// A4 is 440 Hz and 9 semitones above C
+		semitone := int(math.Round(12*math.Log2(freq/440))) + 9
+		a.chromagram[(semitone%12+12)%12] += m * m
+	}
+
+	pc := a.pitchClass
+	var max float64
+	for i, p := range a.chromagram {
+		if p > max {
+			max = p
+			pc = PitchClass(i)
+		}
+	}
+	return pc
+}
+
+// chromaColour returns the colour of the pitch class, from the palette if
+// it has an entry for each class, otherwise from its position on the gradient
+func (f *FFT) chromaColour(pc PitchClass) colorful.Color {
+	if len(f.Palette) == 12 {
+		return f.Palette[pc]
+	}
+
+	pos := float64(pc) / 12
+	if f.Gradient != nil {
+		return f.DrawMode.Interpolate(pos, *f.Gradient)
+	}
+	return colorful.Hsv(pos*360, 1, 1)
+}
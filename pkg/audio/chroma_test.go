@@ -0,0 +1,68 @@
+package audio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lucasb-eyer/go-colorful"
+	"github.com/stretchr/testify/assert"
+)
+
+// analyseChroma returns the loudest pitch class of the first buffer of the samples
+func analyseChroma(t *testing.T, f *FFT, samples []float64) PitchClass {
+	f.ring.Reset()
+	f.Write(encodeF32(samples))
+
+	var a analysis
+	assert.True(t, f.fill(&a))
+	f.spectrum(&a)
+	return a.chroma()
+}
+
+func TestChroma(t *testing.T) {
+	f, err := NewFFT(&Config{Channels: 1, SampleRate: 44100, Format: F32})
+	assert.Nil(t, err)
+	f.FrameSize = 8192
+
+	// The same note has the same pitch class in every octave
+	tests := []struct {
+		freq  float64
+		class string
+	}{
+		{55, "A"},
+		{110, "A"},
+		{440, "A"},
+		{1760, "A"},
+		{65.41, "C"},
+		{261.63, "C"},
+		{2093, "C"},
+		{185, "F#"},
+		{739.99, "F#"},
+		{123.47, "B"},
+	}
+	for _, tt := range tests {
+		pc := analyseChroma(t, f, sine(tt.freq, 1, 44100, 200*time.Millisecond))
+		assert.Equal(t, tt.class, pc.String(), "%vHz", tt.freq)
+	}
+}
+
+func TestChromaColour(t *testing.T) {
+	f, err := NewFFT(DefaultConfig())
+	assert.Nil(t, err)
+
+	// Without a gradient the pitch classes are spread over the hues
+	f.Gradient = nil
+	h, _, _ := f.chromaColour(3).Hsv()
+	assert.InDelta(t, 90, h, 1e-6)
+
+	// The position on the gradient is used instead
+	g := Gradient{{Col: colorful.Color{R: 1}, Pos: 0}, {Col: colorful.Color{B: 1}, Pos: 1}}
+	f.Gradient = &g
+	assert.True(t, f.chromaColour(0).AlmostEqualRgb(colorful.Color{R: 1}))
+
+	// Unless there's a full palette
+	f.Palette = ScriabinPalette()
+	assert.Equal(t, f.Palette[7], f.chromaColour(7))
+	f.Palette = f.Palette[:11]
+	assert.True(t, f.chromaColour(0).AlmostEqualRgb(colorful.Color{R: 1}))
+}
@@ -1,13 +1,15 @@
 package audio
 
 type Config struct {
-	Channels   uint32 // Number of channels, default is 2
-	SampleRate uint32 // Sample rate, default is 44100
+	Channels   uint32       // Number of channels, default is 2
+	SampleRate uint32       // Sample rate, default is 44100
+	Format     SampleFormat // Format of each sample, default is F32
 }
 
 func DefaultConfig() *Config {
 	return &Config{
 		Channels:   2,
 		SampleRate: 44100,
+		Format:     F32,
 	}
 }
@@ -0,0 +1,64 @@
+package audio
+
+import (
+	"errors"
+	"sort"
+)
+
+var ErrInvalidCurve = errors.New("curve needs at least two points with increasing frequencies and positions from 0 to 1")
+
+// Curve contains the control points which map a frequency onto a position
+// on the gradient, or the hues. Positions between the points are linearly
+// interpolated and each position has to live in the range [0,1]
+type Curve []struct {
+	Freq float64 `json:"frequency"`
+	Pos  float64 `json:"position"`
+}
+
+// DefaultCurve spreads the frequencies up to 1200Hz across most of the
+// gradient and squeezes the rest up to 2500Hz into the end of it
+func DefaultCurve() Curve {
+	return Curve{
+		{Freq: 0, Pos: 0},
+		{Freq: 1200, Pos: 310.0 / 320},
+		{Freq: 2500, Pos: 1},
+	}
+}
+
+// Validate checks the curve has enough points, that they are sorted and
+// that their positions are in range
+func (c Curve) Validate() error {
+	if len(c) < 2 {
+		return ErrInvalidCurve
+	}
+	for i := range c {
+		if c[i].Pos < 0 || c[i].Pos > 1 || i > 0 && c[i].Freq <= c[i-1].Freq {
+			return ErrInvalidCurve
+		}
+	}
+	return nil
+}
+
+// Sort orders the points by frequency
+func (c Curve) Sort() {
+	sort.SliceStable(c, func(i, j int) bool { return c[i].Freq < c[j].Freq })
+}
+
+// Position returns where the frequency lies on the curve from 0 to 1,
+// frequencies outside of the curve are clamped to the first or last point
+func (c Curve) Position(freq float64) float64 {
+	if len(c) == 0 {
+		return 0
+	}
+	if freq <= c[0].Freq {
+		return c[0].Pos
+	}
+
+	for i := 1; i < len(c); i++ {
+		if freq < c[i].Freq {
+			lo, hi := c[i-1], c[i]
+			return lo.Pos + (hi.Pos-lo.Pos)*(freq-lo.Freq)/(hi.Freq-lo.Freq)
+		}
+	}
+	return c[len(c)-1].Pos
+}
@@ -0,0 +1,43 @@
+package audio
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCurve(t *testing.T) {
+	c := Curve{
+		{Freq: 100, Pos: 0.2},
+		{Freq: 200, Pos: 0.4},
+		{Freq: 1000, Pos: 0.9},
+	}
+	assert.Nil(t, c.Validate())
+
+	// Positions are interpolated between the points and clamped outside them
+	assert.InDelta(t, 0.3, c.Position(150), 1e-9)
+	assert.InDelta(t, 0.65, c.Position(600), 1e-9)
+	assert.Equal(t, 0.2, c.Position(0))
+	assert.Equal(t, 0.9, c.Position(5000))
+	assert.Equal(t, 0.0, Curve{}.Position(100))
+
+	// Points can be sorted after being moved around
+	c[0].Freq = 1500
+	assert.Equal(t, ErrInvalidCurve, c.Validate())
+	c.Sort()
+	assert.Nil(t, c.Validate())
+	assert.Equal(t, 1500.0, c[2].Freq)
+	assert.Equal(t, ErrInvalidCurve, Curve{{Freq: 100, Pos: 0}}.Validate())
+
+	// Positions have to be on the gradient
+	assert.Equal(t, ErrInvalidCurve, Curve{{Freq: 100, Pos: -0.1}, {Freq: 200, Pos: 1}}.Validate())
+	assert.Equal(t, ErrInvalidCurve, Curve{{Freq: 100, Pos: 0}, {Freq: 200, Pos: 1.5}}.Validate())
+
+	// The curve survives being saved and loaded
+	data, err := json.Marshal(DefaultCurve())
+	assert.Nil(t, err)
+	var loaded Curve
+	assert.Nil(t, json.Unmarshal(data, &loaded))
+	assert.Equal(t, DefaultCurve(), loaded)
+}
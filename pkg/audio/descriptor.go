@@ -0,0 +1,127 @@
+package audio
+
+import (
+	"errors"
+	"math"
+)
+
+var ErrInvalidDescriptor = errors.New("descriptor specified is invalid")
+
+// Fraction of the spectrum's energy which lies below the rolloff frequency
+const rolloffFraction = 0.85
+
+// Descriptor is the feature of the audio which is mapped onto the gradient
+type Descriptor int
+
+const (
+	// PeakFrequency is the frequency estimated by the PitchMode
+	PeakFrequency Descriptor = iota
+	// Centroid is the centre of mass of the spectrum, which is a much
+	// steadier measure of how bright the sound is than the peak
+	Centroid
+	// Spread is how far the spectrum spreads either side of its centroid
+	Spread
+	// Flux is how much the spectrum changes between buffers, it's
+	// normalised by the AGC
+	Flux
+	// Rolloff is the frequency below which most of the energy lies
+	Rolloff
+	// Flatness is how noise-like rather than tonal the spectrum is
+	Flatness
+)
+
+// Descriptors lists every descriptor in the order they're declared
+var Descriptors = []Descriptor{PeakFrequency, Centroid, Spread, Flux, Rolloff, Flatness}
+
+func (d Descriptor) String() string {
+	return [...]string{"Peak", "Centroid", "Spread", "Flux", "Rolloff", "Flatness"}[d]
+}
+
+// hertz reports whether the descriptor is a frequency, which is placed on
+// the gradient by the Scale, rather than a level from 0 to 1
+func (d Descriptor) hertz() bool {
+	return d != Flux && d != Flatness
+}
+
+// SpectralDescriptors are standard features describing the shape
+// of the spectrum of a buffer
+type SpectralDescriptors struct {
+	// Mean frequency in Hz weighted by the magnitude of each frequency
+	Centroid float64
+	// Standard deviation in Hz of the frequencies around the centroid
+	Spread float64
+	// Sum of the increases in magnitude since the previous buffer
+	Flux float64
+	// Frequency in Hz below which 85% of the energy lies
+	Rolloff float64
+	// Ratio of the geometric and arithmetic means of the power, it's
+	// near 1 for noise and near 0 for pure tones
+	Flatness float64
+}
+
+// describe calculates the descriptors of the buffer and adds
+// them to the means since the frequency was last analysed
+func (f *FFT) describe(a *analysis) {
+	d := SpectralDescriptors{Flux: a.flux}
+
+	// The DC offset isn't a frequency so it's skipped
+	var sum, power, logPower float64
+	for k, m := range a.tonal[1:] {
+		freq := float64(k+1) * a.binSize
+		sum += m
+		d.Centroid += freq * m
+		power += m * m
+		logPower += math.Log(m*m + 1e-20)
+	}
+
+	if sum > 0 {
+		d.Centroid /= sum
+		for k, m := range a.tonal[1:] {
+			diff := float64(k+1)*a.binSize - d.Centroid
+			d.Spread += diff * diff * m
+		}
+		d.Spread = math.Sqrt(d.Spread / sum)
+
+		var cumulative float64
+		for k, m := range a.tonal[1:] {
+			cumulative += m * m
+			if cumulative >= rolloffFraction*power {
+				d.Rolloff = float64(k+1) * a.binSize
+				break
+			}
+		}
+
+		n := float64(len(a.tonal) - 1)
+		d.Flatness = math.Min(1, math.Exp(logPower/n)/(power/n))
+	}
+	a.descriptors = d
+
+	// Flux has no natural range so it's normalised to the recent flux
+	a.fluxLevel = a.fluxAGC.normalise(decibels(d.Flux*d.Flux), a.hopDuration, f.AGC)
+
+	a.descriptorSum.Centroid += d.Centroid
+	a.descriptorSum.Spread += d.Spread
+	a.descriptorSum.Flux += a.fluxLevel
+	a.descriptorSum.Rolloff += d.Rolloff
+	a.descriptorSum.Flatness += d.Flatness
+	a.described++
+}
+
+// describedMean returns the mean of the descriptor, which
+// mustn't be the peak frequency, since the last analysis
+func (a *analysis) describedMean(d Descriptor) float64 {
+	sum, n := a.descriptorSum, float64(a.described)
+	switch d {
+	case Centroid:
+		return sum.Centroid / n
+	case Spread:
+		return sum.Spread / n
+	case Flux:
+		return sum.Flux / n
+	case Rolloff:
+		return sum.Rolloff / n
+	case Flatness:
+		return sum.Flatness / n
+	}
+	panic(ErrInvalidDescriptor)
+}
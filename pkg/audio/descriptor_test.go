@@ -0,0 +1,86 @@
+package audio
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/lucasb-eyer/go-colorful"
+	"github.com/stretchr/testify/assert"
+)
+
+// describeSamples returns the descriptors of the last buffer of samples
+// and the analysis which described them
+func describeSamples(f *FFT, samples []float64) (*analysis, SpectralDescriptors) {
+	f.Write(encodeF32(samples))
+
+	var a analysis
+	for f.fill(&a) {
+		f.spectrum(&a)
+		f.onset(&a)
+		f.describe(&a)
+	}
+	return &a, a.descriptors
+}
+
+func TestDescriptors(t *testing.T) {
+	f, err := NewFFT(&Config{Channels: 1, SampleRate: 44100, Format: F32})
+	assert.Nil(t, err)
+	bin := 44100.0 / fftFrames
+
+	// A pure tone is centred on its frequency and isn't flat at all
+	_, d := describeSamples(f, sine(10*bin, 1, 44100, 100*time.Millisecond))
+	assert.InDelta(t, 10*bin, d.Centroid, bin)
+	assert.Less(t, d.Spread, 2*bin)
+	assert.InDelta(t, 10*bin, d.Rolloff, bin)
+	assert.Less(t, d.Flatness, 0.01)
+	assert.Less(t, d.Flux, 0.01)
+
+	// Noise spreads across the whole spectrum and is fairly flat
+	rng := rand.New(rand.NewSource(1))
+	noise := make([]float64, 4410)
+	for i := range noise {
+		noise[i] = rng.Float64() - 0.5
+	}
+	_, d = describeSamples(f, noise)
+	assert.InDelta(t, 44100/4, d.Centroid, 44100/20)
+	assert.Greater(t, d.Spread, 44100/10.0)
+	assert.Greater(t, d.Rolloff, 44100/3.0)
+	assert.Greater(t, d.Flatness, 0.4)
+	assert.Greater(t, d.Flux, 0.0)
+
+	// Silence has no shape
+	_, d = describeSamples(f, make([]float64, 2048))
+	assert.Equal(t, SpectralDescriptors{}, d)
+
+	// Frames too short to have a spectrum are lengthened so they can be described,
+	// even once they've been resampled
+	f.FrameSize, f.ResampleRate = 1, 96000
+	a, _ := describeSamples(f, sine(440, 1, 44100, 10*time.Millisecond))
+	assert.Equal(t, minFrames, len(a.buf))
+}
+
+func TestDescriptorMapping(t *testing.T) {
+	f, err := NewFFT(&Config{Channels: 1, SampleRate: 44100, Format: F32})
+	assert.Nil(t, err)
+
+	// Two tones of the same loudness have their centroid between them,
+	// unlike the peak which picks one of them
+	low, high := sine(300, 1, 44100, 100*time.Millisecond), sine(900, 1, 44100, 100*time.Millisecond)
+	for i := range low {
+		low[i] += high[i]
+	}
+
+	f.Descriptor = Centroid
+	a, _ := describeSamples(f, low)
+	f.analyse(a)
+	assert.InDelta(t, 600, a.frequency, 30)
+	assert.Equal(t, 0, a.described)
+
+	// Descriptors which aren't frequencies are used as the position
+	f.Descriptor = Flatness
+	a, _ = describeSamples(f, low)
+	f.analyse(a)
+	assert.Less(t, a.descriptorLevel, 0.1)
+	assert.Equal(t, f.colour(a), colorful.Hsv(a.descriptorLevel*f.TotalHues, 1, 1))
+}
@@ -8,23 +8,84 @@ import (
 
 type Device struct {
 	Name string
-	id   malgo.DeviceID
+	// Sample formats the device natively supports, if this is
+	// empty then the device didn't report any
+	Formats []SampleFormat
+	// Ranges of channels and sample rates the device natively
+	// supports, zero if the device didn't report them
+	MinChannels, MaxChannels     uint32
+	MinSampleRate, MaxSampleRate uint32
+
+	id malgo.DeviceID
 }
 
 func newDevice(di malgo.DeviceInfo) Device {
 	nameBytes := []byte(di.Name())
 	nameTrimmed := bytes.Trim(nameBytes, "\x00")
 
+	formats := make([]SampleFormat, 0, di.FormatCount)
+	for i := uint32(0); i < di.FormatCount && int(i) < len(di.Formats); i++ {
+		if sf, ok := formatFromMalgo(malgo.FormatType(di.Formats[i])); ok {
+			formats = append(formats, sf)
+		}
+	}
+
 	return Device{
-		Name: string(nameTrimmed),
-		id:   di.ID,
+		Name:          string(nameTrimmed),
+		Formats:       formats,
+		MinChannels:   di.MinChannels,
+		MaxChannels:   di.MaxChannels,
+		MinSampleRate: di.MinSampleRate,
+		MaxSampleRate: di.MaxSampleRate,
+		id:            di.ID,
+	}
+}
+
+// Supports returns whether the device natively supports the sample format
+func (d *Device) Supports(sf SampleFormat) bool {
+	for _, f := range d.Formats {
+		if f == sf {
+			return true
+		}
+	}
+	return false
+}
+
+// Negotiate returns a copy of the config which the device supports. The
+// config's format is kept if possible, otherwise the most precise format
+// the device supports is chosen. The channels and sample rate are clamped
+// to the ranges the device supports
+func (d *Device) Negotiate(conf *Config) Config {
+	negotiated := *conf
+	negotiated.Channels = clamp(conf.Channels, d.MinChannels, d.MaxChannels)
+	negotiated.SampleRate = clamp(conf.SampleRate, d.MinSampleRate, d.MaxSampleRate)
+	if len(d.Formats) == 0 || d.Supports(conf.Format) {
+		return negotiated
+	}
+
+	for _, sf := range preferredFormats {
+		if d.Supports(sf) {
+			negotiated.Format = sf
+			break
+		}
+	}
+	return negotiated
+}
+
+// clamp restricts v to the range [min, max], a bound of zero is ignored
+func clamp(v, min, max uint32) uint32 {
+	if min != 0 && v < min {
+		return min
+	} else if max != 0 && v > max {
+		return max
 	}
+	return v
 }
 
 func (d *Device) config(conf *Config) malgo.DeviceConfig {
 	deviceConfig := malgo.DefaultDeviceConfig(malgo.Capture)
 	deviceConfig.Capture.DeviceID = d.id.Pointer()
-	deviceConfig.Capture.Format = malgo.FormatF32
+	deviceConfig.Capture.Format = conf.Format.malgo()
 	deviceConfig.Capture.Channels = conf.Channels
 	deviceConfig.SampleRate = conf.SampleRate
 	deviceConfig.PerformanceProfile = malgo.LowLatency
@@ -2,6 +2,7 @@ package audio
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"testing"
 	"time"
@@ -30,44 +31,45 @@ func TestExampleBasic(t *testing.T) {
 
 	// 2.
 	buf := bytes.NewBuffer(nil)
-	err = a.StartCapture(device, buf, DefaultConfig())
+	errs := make(chan error)
+	go func() {
+		errs <- a.Run(context.Background(), a.NewCaptureSource(device, DefaultConfig()), buf)
+	}()
 	fmt.Println("SIMPLE Started capture 1")
-	if err != nil {
-		panic(err)
-	}
 
 	// 3.
-	go func() {
-		time.Sleep(3 * time.Second)
-		a.StopCapture()
-	}()
+	time.Sleep(time.Second)
+	a.Stop()
 
-	err = <-a.Done
-	if err != nil {
+	err = <-errs
+	if err != context.Canceled {
 		panic(err)
 	}
 	fmt.Println("SIMPLE Ended capture 1")
 
 	// 4.
-	err = a.StartCapture(device, buf, DefaultConfig())
-	if err != nil {
-		panic(err)
-	}
+	go func() {
+		errs <- a.Run(context.Background(), a.NewCaptureSource(device, DefaultConfig()), buf)
+	}()
 	fmt.Println("SIMPLE Started capture 2")
 
+	time.Sleep(time.Second)
 	go func() {
-		time.Sleep(3 * time.Second)
-		err = a.StartCapture(devices[1], buf, DefaultConfig())
-		if err != nil {
-			panic(err)
-		}
-		fmt.Println("SIMPLE Device changed 2")
-		time.Sleep(3 * time.Second)
-		a.StopCapture()
+		errs <- a.Run(context.Background(), a.NewCaptureSource(devices[len(devices)-1], DefaultConfig()), buf)
 	}()
 
-	err = <-a.Done
-	if err != nil {
+	// Changing the device stops the first capture
+	err = <-errs
+	if err != context.Canceled {
+		panic(err)
+	}
+	fmt.Println("SIMPLE Device changed 2")
+
+	time.Sleep(time.Second)
+	a.Stop()
+
+	err = <-errs
+	if err != context.Canceled {
 		panic(err)
 	}
 	fmt.Println("SIMPLE Ended capture 2")
@@ -90,31 +92,30 @@ func TestExampleComplex(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	device := devices[1]
+	device := devices[len(devices)-1]
 	fmt.Printf("COMPLEX Chosen device: %s\n", device.Name)
 
 	w, err := NewFFT(DefaultConfig())
 	if err != nil {
 		t.Error(err)
 	}
-	err = a.StartCapture(device, w, DefaultConfig())
-	fmt.Println("COMPLEX Started capture 1")
-	if err != nil {
-		t.Error(err)
-	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	go w.Run(ctx)
+
+	errs := make(chan error)
 	go func() {
-		time.Sleep(10 * time.Second)
-		a.StopCapture()
+		errs <- a.Run(ctx, a.NewCaptureSource(device, DefaultConfig()), w)
 	}()
+	fmt.Println("COMPLEX Started capture 1")
 
 	// 3.
 loop:
 	for {
 		select {
-		case err := <-a.Done:
-			w.Stop()
-			if err != nil {
+		case err := <-errs:
+			if err != context.DeadlineExceeded {
 				panic(err)
 			}
 			fmt.Println("COMPLEX Ended capture 1")
@@ -1,82 +1,183 @@
 package audio
 
 import (
-	"bufio"
-	"bytes"
-	"encoding/binary"
+	"context"
 	"errors"
-	"fmt"
-	"io"
 	"math"
 	"math/cmplx"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/lucasb-eyer/go-colorful"
 
+	"currents/internal/dsp"
 	"currents/internal/fft"
 )
 
 var ErrChannelNum = errors.New("channel number is unsupported")
 var ErrSampleRate = errors.New("sample rate is unsupported")
+var ErrRunning = errors.New("fft is already running")
+
+// How many frames are analysed each time the frequency is calculated
+// if FFT.FrameSize isn't set
+const fftFrames = 1024
+
+// Fewest frames which are analysed, anything shorter has no frequencies
+const minFrames = 2
+
+// Limits of the configs FFT can process
+const (
+	maxChannels   = 32
+	minSampleRate = 8000
+	maxSampleRate = 384000
+	maxFrameSize  = maxChannels * 4
+)
+
+// How many samples the ring buffer holds, this is around
+// a second and a half of stereo audio at 44100 Hz
+const ringSize = 1 << 17
 
 type FFT struct {
-	buffer    *bytes.Buffer // Writer the captured audio should be written to
-	abortChan chan error    // Tells FFT to stop processing input on its writer
-	conf      *Config       // Config tells FFT how to process the audio data
+	ring *ring        // Buffer the captured audio is decoded into
+	conf atomic.Value // *Config which tells FFT how to process the audio data
+
+	m       sync.Mutex    // Guards the settings and the fields used to control the analysis loop
+	running bool          // Whether the analysis loop is running
+	ticker  *time.Ticker  // Ticker for the sample rate, nil if not running
+	period  time.Duration // Period of the ticker
+	tempo   atomic.Value  // Tempo of the audio
+	stopped int32         // Whether the audio was stopped deliberately, accessed atomically
+
+	subsM sync.Mutex                 // Guards subs
+	subs  map[chan Spectrum]struct{} // Chans which receive the spectrum of each buffer
+
+	// Owned by the writer, these let Write decode the audio without allocating
+	scratch   []float32          // Decoded samples waiting to be added to the ring
+	carry     [maxFrameSize]byte // Partial frame left over from the previous write
+	carryLen  int                // Length of the partial frame
+	carryConf *Config            // Config the partial frame was written with
 
 	// What type of interpolation to use for drawing the colours
 	DrawMode InterpolateMode
 	// Chan which returns the most recent calculated colour
 	Hues chan colorful.Color
-	// When FFT has stopped processing, due to error or not,
-	// the error message is sent here
-	Done chan error
+	// Chan which returns the colour of each side of the audio in the
+	// stereo modes, it's sent alongside Hues but is never blocked on
+	StereoHues chan StereoColour
+	// Chan which returns the energy in each of Bands for the most recent
+	// buffer, it's sent alongside Hues but is never blocked on
+	Energies chan []BandEnergy
+	// Chan which returns beats as they're detected, beats
+	// are dropped if they aren't received in time
+	Beats chan Beat
+	// How the colour changes on each beat
+	BeatMode BeatMode
+	// Whether frequency is calculated once per beat, rather than every
+	// SampleRate, when the tempo of the audio is known
+	TempoSync bool
+	// How many times larger than the recent average the spectral
+	// flux must be to be a beat, lower values detect more beats
+	BeatSensitivity float64
+	// Frequency bands whose energy is sent on Energies
+	Bands []Band
 	// Gradient to interpolate colours with, if this is not specified
 	// then colours are interpolated over the HSV spectrum
 	Gradient *Gradient
-	// Whether the hue colour change should be dampened
-	Damp bool
+	// Window applied to the audio before it's analysed
+	Window Window
+	// How the analysed audio is turned into a colour
+	Mapping ColourMapping
+	// Colours of each pitch class, from C to B, used by the Chroma
+	// mapping. If this doesn't have 12 colours the gradient is used
+	Palette []colorful.Color
+	// How the loudness of the audio changes the colour
+	Dynamics Dynamics
+	// Noise gate which fades to the idle effect while the audio is silent
+	Gate Gate
+	// How quickly features are normalised to the level of the audio
+	AGC AGC
+	// Whether the channels are visualised together or separately
+	ChannelMode ChannelMode
+	// Whether the harmonic and percussive parts of the audio are separated
+	Separation Separation
+	// Which features are routed to the colour by the Routed mapping
+	Routes Routes
+	// Which descriptor is mapped onto the gradient by FrequencyRamp
+	Descriptor Descriptor
+	// How the frequency which is visualised is estimated
+	PitchMode PitchMode
+	// Filter which smooths the frequency or the colour
+	Smoothing Smoothing
+	// How frequencies are converted into a position on the gradient
+	Scale FrequencyScale
+	// Lowest frequency on the log, Mel and Bark scales
+	MinFreq float64
 	// FFT will clamp the maximum frequency to this value
 	MaxFreq float64
-	// The upper range of frequencies the program considers useful.
-	// After this barrier, the colour changes very slowly in relation
-	// to change in f
-	MaxUsefulFrequency float64
+	// Control points which map frequencies onto the gradient when the
+	// CurveScale is used
+	Curve Curve
 	// How many unique hues should the colour spectrum have
 	TotalHues float64
-	// The hue number at which the MaxUsefulFrequency is reached,
-	// e.g. from 200 total hues, after 180 hues MaxUsefulFrequency
-	// will take effect
-	UsefulFrequencyHue float64
 	// How often we want to use the values from the audio buffer
 	SampleRate time.Duration
-
-	// Ticker for the sample rate
-	ticker *time.Ticker
+	// How many frames of audio are analysed each time the frequency is
+	// calculated, larger frames increase the frequency resolution but also
+	// the latency. If this is zero then 1024 frames are analysed
+	FrameSize int
+	// How many frames the analysis advances between each frame, if this is
+	// less than FrameSize then consecutive frames overlap so colours are
+	// updated more often. If this is zero then frames don't overlap
+	HopSize int
+	// Length of the FFT, frames shorter than this are zero padded which
+	// interpolates the spectrum without adding latency. If this is less
+	// than FrameSize then FrameSize is used
+	FFTSize int
+	// If this isn't zero then the audio is resampled to this rate before
+	// it's analysed, so the frequency resolution is the same regardless
+	// of the rate of the source
+	ResampleRate uint32
+
+	// Signalled by Write when new audio has been added to the ring buffer
+	ready chan struct{}
+	// Figures describing how much work the analysis loop is doing
+	stats stats
 }
 
 func NewFFT(conf *Config) (*FFT, error) {
-	if conf.Channels != 2 {
-		return nil, ErrChannelNum
-	} else if conf.SampleRate != 44100 {
-		return nil, ErrSampleRate
+	if err := validateConfig(conf); err != nil {
+		return nil, err
 	}
 
 	f := &FFT{
-		buffer:             bytes.NewBuffer(make([]byte, 0)),
-		abortChan:          make(chan error),
-		conf:               conf,
-		DrawMode:           Blended,
-		Hues:               make(chan colorful.Color, 1),
-		Done:               make(chan error),
-		MaxFreq:            2500,
-		MaxUsefulFrequency: 1200,
-		TotalHues:          320,
-		UsefulFrequencyHue: 310,
-		Damp:               true,
-		SampleRate:         250 * time.Millisecond,
-	}
-	go f.start()
+		ring:            newRing(ringSize),
+		scratch:         make([]float32, 0, 4096),
+		DrawMode:        Blended,
+		Window:          Hann,
+		PitchMode:       Parabolic,
+		Dynamics:        DefaultDynamics(),
+		AGC:             DefaultAGC(),
+		Gate:            DefaultGate(),
+		Hues:            make(chan colorful.Color, 1),
+		Energies:        make(chan []BandEnergy, 1),
+		StereoHues:      make(chan StereoColour, 1),
+		Beats:           make(chan Beat, 16),
+		BeatSensitivity: 1.5,
+		Bands:           DefaultBands(),
+		MinFreq:         20,
+		MaxFreq:         2500,
+		Curve:           DefaultCurve(),
+		TotalHues:       320,
+		Smoothing:       DefaultSmoothing(),
+		Separation:      DefaultSeparation(),
+		Routes:          DefaultRoutes(),
+		SampleRate:      250 * time.Millisecond,
+		ready:           make(chan struct{}, 1),
+		stats:           stats{since: time.Now()},
+	}
+	c := *conf
+	f.conf.Store(&c)
 	return f, nil
 }
 
@@ -88,158 +189,494 @@ func MustCreateNewFFT(conf *Config) *FFT {
 	return f
 }
 
+// Lock stops the analysis loop from reading the settings until Unlock is
+// called, once Run has been called they must only be changed while locked
+func (f *FFT) Lock() {
+	f.m.Lock()
+}
+
+func (f *FFT) Unlock() {
+	f.m.Unlock()
+}
+
+// ChangeSampleRate changes how often the frequency is calculated
 func (f *FFT) ChangeSampleRate(d time.Duration) {
-	if f.ticker != nil {
-		f.ticker.Reset(d)
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	f.SampleRate = d
+	f.retune()
+}
+
+// retune changes the period of the ticker to one beat if TempoSync is on
+// and the tempo is known, otherwise to SampleRate. f.m must be held
+func (f *FFT) retune() {
+	p := f.SampleRate
+	if t := f.Tempo(); f.TempoSync && t.Confidence >= minTempoConfidence {
+		p = t.Period()
+	}
+	if f.ticker != nil && p != f.period {
+		f.ticker.Reset(p)
 	}
+	f.period = p
 }
 
-// Write implements io.Writer
-func (f *FFT) Write(p []byte) (n int, err error) {
-	if f.buffer == nil {
-		return 0, fmt.Errorf("internal buffer is nil")
+func (f *FFT) tickPeriod() time.Duration {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	return f.period
+}
+
+func validateConfig(conf *Config) error {
+	if conf.Channels < 1 || conf.Channels > maxChannels {
+		return ErrChannelNum
+	} else if conf.SampleRate < minSampleRate || conf.SampleRate > maxSampleRate {
+		return ErrSampleRate
+	} else if !conf.Format.Valid() {
+		return ErrSampleFormat
 	}
-	return f.buffer.Write(p)
+	return nil
 }
 
-func (f *FFT) start() {
-	channelNum := int(f.conf.Channels)
-	sampleRate := int(f.conf.SampleRate)
+// SetFormat changes the layout of the frames FFT expects to be written
+// to it, any audio which hasn't been processed yet is discarded. It must
+// not be called while audio is being written to FFT
+func (f *FFT) SetFormat(conf Config) error {
+	if err := validateConfig(&conf); err != nil {
+		return err
+	}
 
-	buf := make([]byte, 0, 1024*8)
+	f.ring.Reset()
+	f.conf.Store(&conf)
+	return nil
+}
+
+// Stopped implements StopWriter, it tells FFT the audio has stopped being
+// written deliberately so the colour isn't faded to the idle effect as if
+// the audio had stalled. Writing more audio undoes it
+func (f *FFT) Stopped() {
+	atomic.StoreInt32(&f.stopped, 1)
+}
+
+// Dropped returns how many samples have been discarded because
+// the analysis fell behind the audio being written
+func (f *FFT) Dropped() uint64 {
+	return f.ring.Dropped()
+}
+
+// Stats returns figures describing how much work the analysis loop has
+// done since Stats was last called
+func (f *FFT) Stats() Stats {
+	st := f.stats.reset()
+	st.Dropped = f.Dropped()
+	return st
+}
+
+func (f *FFT) config() *Config {
+	return f.conf.Load().(*Config)
+}
+
+// Write implements io.Writer, it decodes the audio into the ring
+// buffer without blocking or allocating so it's safe to call from
+// an audio callback. It must only be called by one goroutine at a time
+func (f *FFT) Write(p []byte) (n int, err error) {
+	atomic.StoreInt32(&f.stopped, 0)
 
-	var displayFreq float64 // Interpolated frequency displayed on the LED lights
-	var frequency float64   // The max frequency of the current buffer
-	var oldFreq float64     // The max frequency of the previous buffer
+	conf := f.config()
+	if conf != f.carryConf {
+		f.carryLen = 0
+		f.carryConf = conf
+	}
+	total := len(p)
+	channels := int(conf.Channels)
+	frameSize := channels * conf.Format.Size()
+
+	// Complete the partial frame from the previous write
+	if f.carryLen > 0 {
+		n := copy(f.carry[f.carryLen:frameSize], p)
+		f.carryLen += n
+		p = p[n:]
+		if f.carryLen < frameSize {
+			return total, nil
+		}
+		f.ring.Write(conf.Format.Decode(f.scratch[:0], f.carry[:frameSize]), channels)
+		f.carryLen = 0
+	}
 
-	var update time.Time // Time when the fft was last calculated
+	// Decode the whole frames in chunks which fit in the scratch buffer
+	chunk := cap(f.scratch) / channels * frameSize
+	for len(p) >= frameSize {
+		n := len(p) - len(p)%frameSize
+		if n > chunk {
+			n = chunk
+		}
+		f.ring.Write(conf.Format.Decode(f.scratch[:0], p[:n]), channels)
+		p = p[n:]
+	}
+	f.carryLen = copy(f.carry[:], p)
 
-	f.ticker = time.NewTicker(f.SampleRate)
+	// Wake up the analysis loop if it's not already been woken
+	select {
+	case f.ready <- struct{}{}:
+	default:
+	}
 
+	return total, nil
+}
+
+// analysis holds the state and scratch buffers owned by the analysis loop
+type analysis struct {
+	buf       []float32 // Interleaved samples read from the ring buffer
+	filled    int       // How many samples of buf have been read
+	hop       int       // How many samples buf advances by once it's been analysed
+	full      bool      // Whether buf was full the last time it was filled
+	mix       mix       // How the channels are mixed down into mono
+	mono      []float32 // Samples mixed down into mono
+	resampled []float32 // Mono samples resampled to the analysis rate
+	samples   []float32 // Mono samples at the analysis rate, either mono or resampled
+	tapered   []float32 // Samples with the window applied
+	window    []float32 // Coefficients of the window applied before the FFT
+	windowed  Window    // Which window the coefficients belong to
+	pending   bool      // Whether the ticker has fired since the last analysis
+
+	analysisRate int         // Sample rate of samples
+	windowSum    float64     // Sum of the window coefficients
+	windowPower  float64     // Sum of the squares of the window coefficients
+	fftSize      int         // Length of the FFT including the zero padding
+	padded       []complex64 // Tapered samples zero padded to the length of the FFT
+	fftData      []complex64 // FFT of the padded samples
+	binSize      float64     // Difference in frequency between each magnitude
+	magnitudes   []float64   // Magnitude of each frequency up to the Nyquist frequency
+	tonal        []float64   // Magnitudes the frequency is found from, the harmonic part when separating
+	transient    []float64   // Magnitudes the onsets are found from, the percussive part when separating
+	frequencies  []float64   // Frequency of each magnitude, shared with the subscribers
+	frequencyBin float64     // Bin size the frequencies were calculated with
+	differences  []float64   // Scratch space for the differences YIN finds the period from
+
+	prevMagnitudes []float64     // Magnitudes of the previous buffer
+	flux           float64       // Spectral flux of the current buffer
+	fluxHistory    []float64     // Spectral flux of the recent buffers
+	fluxIndex      int           // Index of the oldest flux in fluxHistory
+	sinceBeat      time.Duration // Duration of audio analysed since the last beat
+	lastBeat       time.Time     // Time when the last beat was detected
+	beats          int           // How many beats have been detected
+	hopDuration    time.Duration // Duration of audio buf advances by
+	phase          float64       // Position in the gradient when cycling through it
+	cycled         time.Time     // Time when the phase was last advanced
+
+	envelope    []float64     // Spectral flux of each buffer over the tempo window
+	envelopeHop time.Duration // Duration of each buffer in the envelope
+	centred     []float64     // Envelope with its mean subtracted
+	correlation []float64     // Autocorrelation of the envelope
+
+	frequency float64   // The max frequency of the current buffer
+	update    time.Time // Time when the fft was last calculated
+
+	hpss          *dsp.HPSS // Separates the spectrum, nil unless separating
+	harmonic      []float64 // Harmonic part of the magnitudes
+	percussive    []float64 // Percussive part of the magnitudes
+	percussiveAGC follower  // Normalises the power of the percussive part
+
+	descriptors     SpectralDescriptors // Descriptors of the current buffer
+	descriptorSum   SpectralDescriptors // Sum of the descriptors since the last analysis
+	described       int                 // How many buffers are in descriptorSum
+	fluxAGC         follower            // Normalises the flux into a level
+	fluxLevel       float64             // Flux of the current buffer normalised by fluxAGC
+	descriptorLevel float64             // Mean of the descriptor which isn't a frequency
+
+	smoothing Smoothing  // Settings the smoothers were created with
+	smoothers []Smoother // Filters for the position or each OkLab coordinate
+
+	meanSquare    float64      // Mean square of the samples averaged over the loudness window
+	loudness      float64      // Loudness of the audio in dBFS
+	loudnessLevel float64      // Loudness normalised by the AGC
+	loudnessAGC   follower     // Range the loudness has recently been in
+	bandAGC       []follower   // Range each band has recently been in
+	energies      []BandEnergy // Energy in each band of the current buffer
+
+	colour    colorful.Color // Colour of the last buffer before the gate
+	lastFrame time.Time      // Time when the last buffer was analysed
+	gated     time.Time      // Time when the gate was last updated
+	silence   time.Duration  // How long the audio has been silent for
+	idle      float64        // How far the colour has faded to the idle effect
+
+	chromagram [12]float64 // Power of the buffer in each pitch class
+	pitchClass PitchClass  // The loudest pitch class of the current buffer
+}
+
+// Run processes the audio written to FFT and sends the resulting colours
+// on Hues until ctx is cancelled, it then returns ctx.Err(). Only one call
+// to Run may be active at a time, but FFT can be run again once it returns
+func (f *FFT) Run(ctx context.Context) error {
+	f.m.Lock()
+	if f.running {
+		f.m.Unlock()
+		return ErrRunning
+	}
+	f.running = true
+	f.period = f.SampleRate
+	f.ticker = time.NewTicker(f.period)
+	ticker := f.ticker
+	f.m.Unlock()
+
+	defer func() {
+		f.m.Lock()
+		f.running = false
+		f.ticker.Stop()
+		f.ticker = nil
+		f.m.Unlock()
+	}()
+
+	var a analysis
+	var sides [2]analysis // Left and right, or mid and side, in the stereo modes
 	for {
+		// Sleep until there's new audio or the ticker fires
 		select {
-		case err := <-f.abortChan:
-			close(f.Hues)
-			f.ticker.Stop()
-			f.Done <- err
-			return
-		default:
-			// Populate the buffer
-			n, err := fill(bufio.NewReader(f.buffer), buf)
-			buf = buf[:n]
-			if err != nil && err != io.ErrUnexpectedEOF {
-				if err == io.EOF || err == io.ErrUnexpectedEOF {
-					continue
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			a.pending = true
+			f.m.Lock()
+			f.retune()
+
+			// Keep fading out if the audio has stopped altogether
+			if f.ChannelMode != Mono {
+				left, leftOk := f.stalled(&sides[0], f.period)
+				right, rightOk := f.stalled(&sides[1], f.period)
+				if leftOk && rightOk {
+					select {
+					case <-f.StereoHues:
+					default:
+					}
+					f.StereoHues <- StereoColour{Left: left, Right: right}
+				}
+			}
+			colour, ok := f.stalled(&a, f.period)
+			f.m.Unlock()
+
+			if ok {
+				select {
+				case f.Hues <- colour:
+				case <-ctx.Done():
+					return ctx.Err()
 				}
-				close(f.Hues)
-				f.Done <- err
-				return
 			}
+			continue
+		case <-f.ready:
+		}
+
+		// Process every buffer which can be filled from the new audio,
+		// the settings can't be changed while a buffer is processed
+		f.m.Lock()
+		for f.fill(&a) {
+			began := time.Now()
+			f.spectrum(&a)
+			f.separate(&a)
 
 			// Frequency only updated every delta t, colour
 			// updated instantaneously
-			select {
-			case <-f.ticker.C:
-				update = time.Now()
-
-				// Get all the float values for each sample in the input samples
-				monoFrameCount := len(buf) / (channelNum * sampleSizeInBytes) // We mix down the samples into mono so we lose half the frames
-				samples := make([]float32, 0, monoFrameCount)
-				for i := 0; i < len(buf); i += channelNum * sampleSizeInBytes {
-					// Value of th left sample
-					leftBytes := buf[i : i+sampleSizeInBytes]
-					leftBits := binary.LittleEndian.Uint32(leftBytes)
-					leftFloat := math.Float32frombits(leftBits)
-
-					// Value of the right sample
-					rightBytes := buf[i+sampleSizeInBytes : i+channelNum*sampleSizeInBytes]
-					rightBits := binary.LittleEndian.Uint32(rightBytes)
-					rightFloat := math.Float32frombits(rightBits)
-
-					// Mix them together
-					mixedFloat := (leftFloat + rightFloat) / float32(channelNum)
-					samples = append(samples, mixedFloat)
+			analysed := a.pending
+			if a.pending {
+				f.analyse(&a)
+				f.tempo.Store(a.tempo())
+				a.pending = false
+			}
+			beat, isBeat := f.onset(&a)
+			f.describe(&a)
+			f.publish(&a, began)
+			f.envelope(&a)
+			f.loudness(&a)
+			energies := f.energies(&a)
+			colour := f.shade(&a, began)
+			if f.ChannelMode != Mono {
+				stereo := f.stereo(&a, &sides, analysed, began)
+				select {
+				case <-f.StereoHues:
+				default:
 				}
+				f.StereoHues <- stereo
+			}
+			f.stats.record(time.Since(began), analysed)
 
-				// Reversing the Nyquist–Shannon sampling theorem to see the maximum frequency we are trying to achieve
-				maxInfo := sampleRate / channelNum
-				// This is the length the program uses to find the freq with
-				// the highest magnitude, this is half the buffer length because
-				// the FFT is mirrored along the centre, thus only half the length
-				// needs to be used
-				usefulMonoFrameCount := monoFrameCount / channelNum
-				// This represents the difference in frequency between each index of the FFT'd array
-				freqBinSize := maxInfo / usefulMonoFrameCount
-
-				// Perform the FFT on the samples and get the frequency with the largest magnitude
-				fftData := fft.FFTReal(samples)
-
-				var max float64
-				var index int
-
-				// FFT is mirrored so we only need the first half of the samples
-				for i := 0; i < usefulMonoFrameCount; i++ {
-					e := cmplx.Abs(complex128(fftData[i]))
-					if e > max {
-						max = e
-						index = i
-					}
+			// Nothing may be reading the energies so the
+			// previous ones are replaced rather than blocking
+			select {
+			case <-f.Energies:
+			default:
+			}
+			f.Energies <- energies
+			if isBeat {
+				select {
+				case f.Beats <- beat:
+				default:
 				}
+			}
 
-				oldFreq = frequency
-				frequency = math.Min(float64(freqBinSize*index), f.MaxFreq)
-			default:
+			f.m.Unlock()
+			select {
+			case f.Hues <- colour:
+			case <-ctx.Done():
+				return ctx.Err()
 			}
+			f.m.Lock()
+		}
+		f.m.Unlock()
+	}
+}
 
-			displayFreq = frequency
+// fill reads from the ring buffer and returns whether the buffer is full
+func (f *FFT) fill(a *analysis) bool {
+	conf := f.config()
+	channelNum := int(conf.Channels)
+
+	// The buffer must hold a whole number of frames
+	size, hop := f.frameCount(conf)
+	if size, hop := size*channelNum, hop*channelNum; len(a.buf) != size || a.hop != hop {
+		a.buf = make([]float32, size)
+		a.filled = 0
+		a.hop = hop
+		a.full = false
+	}
 
-			// Damp if needed
-			if f.Damp {
-				since := time.Now().Sub(update)
-				delta := float64(since.Nanoseconds()) / float64(f.SampleRate.Nanoseconds())
-				displayFreq = oldFreq + (math.Sqrt(delta))*(frequency-oldFreq)
-			}
+	// Keep the frames which overlap with the next buffer
+	if a.full {
+		a.filled = copy(a.buf, a.buf[a.hop:])
+		a.full = false
+	}
 
-			// Calculate the corresponding hue for the colour
-			var hue float64
-			if displayFreq > f.MaxUsefulFrequency {
-				hue = f.UsefulFrequencyHue + (f.TotalHues-f.UsefulFrequencyHue)*(displayFreq/f.MaxFreq)
-			} else {
-				hue = displayFreq / f.MaxUsefulFrequency * f.UsefulFrequencyHue
-			}
+	a.filled += f.ring.Read(a.buf[a.filled:])
+	a.full = a.filled == len(a.buf)
+	return a.full
+}
 
-			// Create the colour
-			var colour colorful.Color
-			if f.Gradient != nil {
-				colour = f.DrawMode.Interpolate(hue/f.TotalHues, *f.Gradient)
-			} else {
-				colour = colorful.Hsv(hue, 1, 1)
-			}
-			f.Hues <- colour
+// frameCount returns how many frames are needed to fill the buffer and how
+// many frames the buffer advances by each time, when resampling enough
+// frames are read so that FrameSize frames are analysed at the resampled rate
+func (f *FFT) frameCount(conf *Config) (size, hop int) {
+	size, hop = f.FrameSize, f.HopSize
+	if size <= 0 {
+		size = fftFrames
+	}
+	if hop <= 0 || hop > size {
+		hop = size
+	}
+
+	if f.ResampleRate != 0 {
+		size = size * int(conf.SampleRate) / int(f.ResampleRate)
+		hop = hop * int(conf.SampleRate) / int(f.ResampleRate)
+		if hop < 1 {
+			hop = 1
+		}
+	}
+	if size < minFrames {
+		size = minFrames
+	}
+	return size, hop
+}
+
+// spectrum calculates the magnitude of each frequency in the buffer
+func (f *FFT) spectrum(a *analysis) {
+	conf := f.config()
+	channelNum := int(conf.Channels)
+	sampleRate := int(conf.SampleRate)
+
+	// Mix each frame down into mono
+	a.mono = downmix(a.mono[:0], a.buf, channelNum, a.mix)
+
+	a.samples = a.mono
+	a.analysisRate = sampleRate
+	if f.ResampleRate != 0 && int(f.ResampleRate) != sampleRate {
+		a.analysisRate = int(f.ResampleRate)
+		a.resampled = dsp.Resample(a.resampled[:0], a.mono, sampleRate, a.analysisRate)
+		a.samples = a.resampled
+	}
+
+	// Taper the ends of a copy of the buffer to reduce spectral leakage
+	samples := append(a.tapered[:0], a.samples...)
+	a.tapered = samples
+	if w := f.Window; len(a.window) != len(samples) || a.windowed != w {
+		a.window = w.coefficients(a.window[:0], len(samples))
+		a.windowed = w
+		a.windowSum, a.windowPower = 0, 0
+		for _, c := range a.window {
+			a.windowSum += float64(c)
+			a.windowPower += float64(c) * float64(c)
 		}
 	}
+	dsp.ApplyWindow(samples, a.window)
+
+	// Zero pad the samples up to the length of the FFT
+	a.fftSize = f.FFTSize
+	if a.fftSize < len(samples) {
+		a.fftSize = len(samples)
+	}
+	// This represents the difference in frequency between each index of the FFT'd array
+	a.binSize = float64(a.analysisRate) / float64(a.fftSize)
+
+	// Perform the FFT on the samples, it's mirrored along the centre,
+	// so only half the length, up to the Nyquist frequency, is kept
+	a.padded = dsp.ToComplexPadded(a.padded[:0], samples, a.fftSize)
+	a.fftData = fft.FFTTo(a.fftData, a.padded)
+	a.magnitudes = a.magnitudes[:0]
+	for _, c := range a.fftData[:a.fftSize/2] {
+		a.magnitudes = append(a.magnitudes, cmplx.Abs(complex128(c)))
+	}
+	a.tonal, a.transient = a.magnitudes, a.magnitudes
 }
 
-func (f *FFT) Stop() {
-	f.abortChan <- nil
+// analyse estimates the frequency, or the descriptor which replaces it,
+// and the pitch class of the buffer
+func (f *FFT) analyse(a *analysis) {
+	a.update = time.Now()
+	switch {
+	case f.Descriptor == PeakFrequency:
+		a.frequency = math.Min(f.pitch(a), f.MaxFreq)
+	case a.described == 0:
+		// Nothing has been described since the last analysis
+	case f.Descriptor.hertz():
+		a.frequency = math.Min(a.describedMean(f.Descriptor), f.MaxFreq)
+	default:
+		a.descriptorLevel = a.describedMean(f.Descriptor)
+	}
+	a.descriptorSum, a.described = SpectralDescriptors{}, 0
+	a.pitchClass = a.chroma()
 }
 
-// fill ignores io.EOF and io.ErrUnexpectedEOF and waits until the buffer
-// is full before returning. This is useful because a lot of times the fft
-// buffer will be read from at around the same speed it's written to so it
-// can't fill up completely to its full capacity, this fixes that
-func fill(reader io.Reader, buf []byte) (int, error) {
-	totalN := 0
+// shade returns the colour of the buffer after the beat, the dynamics and
+// the gate have been applied to it
+func (f *FFT) shade(a *analysis, began time.Time) colorful.Color {
+	colour := f.beatColour(a, f.colour(a))
+	colour = f.Dynamics.Apply(colour, f.level(a))
+	colour = f.pulse(a, colour)
+	a.colour, a.lastFrame, a.gated = colour, began, began
+	return f.gate(a, colour, a.hopDuration, a.loudness < f.Gate.Threshold)
+}
 
-	for {
-		n, err := io.ReadFull(reader, buf[totalN:cap(buf)])
-		totalN += n
-		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
-			return totalN, err
-		}
+// rampPosition returns the smoothed position of the frequency on the scale,
+// or the level of the descriptor if it isn't a frequency
+func (f *FFT) rampPosition(a *analysis) float64 {
+	pos := a.descriptorLevel
+	if f.Descriptor.hertz() {
+		pos = f.position(a.frequency)
+	}
+	return f.smoothPosition(a, pos)
+}
 
-		if cap(buf)-totalN == 0 {
-			return totalN, nil
+// colour returns the colour which should currently be displayed
+func (f *FFT) colour(a *analysis) colorful.Color {
+	switch f.Mapping {
+	case FrequencyRamp:
+		pos := f.rampPosition(a)
+		if f.Gradient != nil {
+			return f.smoothColour(a, f.DrawMode.Interpolate(pos, *f.Gradient))
 		}
+		return f.smoothColour(a, colorful.Hsv(pos*f.TotalHues, 1, 1))
+	case Chroma:
+		return f.smoothColour(a, f.chromaColour(a.pitchClass))
+	case Routed:
+		return f.smoothColour(a, f.route(a))
 	}
+
+	panic(ErrInvalidMapping)
 }
@@ -0,0 +1,244 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// runFFT runs the FFT in the background, the returned
+// func stops it and checks that it stopped cleanly
+func runFFT(t *testing.T, f *FFT) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() { errs <- f.Run(ctx) }()
+
+	return func() {
+		cancel()
+		assert.Equal(t, context.Canceled, <-errs)
+	}
+}
+
+// detectFrequency streams the WAV file into an FFT and waits for it to
+// produce the hue which corresponds to freq
+func detectFrequency(t *testing.T, wav []byte, freq float64, configure func(f *FFT)) {
+	ws, err := NewWAVSource(bytes.NewReader(wav))
+	assert.Nil(t, err)
+	ws.RealTime = true
+
+	conf := ws.Format()
+	f, err := NewFFT(&conf)
+	assert.Nil(t, err)
+	configure(f)
+	defer runFFT(t, f)()
+	assert.Nil(t, ws.Start(f, func(err error) { assert.Nil(t, err) }))
+	defer ws.Stop()
+
+	// The frequency is quantised into bins so it won't be exact
+	expected := f.position(freq) * f.TotalHues
+	timeout := time.After(3 * time.Second)
+	for {
+		select {
+		case c := <-f.Hues:
+			h, _, _ := c.Hsv()
+			if math.Abs(h-expected) < 10 {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("fft did not detect the %vHz sine wave", freq)
+		}
+	}
+}
+
+func TestFFTFrequency(t *testing.T) {
+	tests := []struct {
+		channels   int
+		sampleRate int
+		bits       int
+		resample   uint32
+	}{
+		{2, 44100, 16, 0},
+		{1, 48000, 16, 0},
+		{6, 96000, 24, 0},
+		{1, 96000, 32, 44100},
+		{2, 22050, 8, 44100},
+	}
+
+	for _, tt := range tests {
+		wav := encodeWAV(sine(440, tt.channels, tt.sampleRate, 3*time.Second), tt.channels, tt.sampleRate, tt.bits, false)
+		detectFrequency(t, wav, 440, func(f *FFT) { f.ResampleRate = tt.resample })
+	}
+}
+
+func TestFFTWindows(t *testing.T) {
+	wav := encodeWAV(sine(440, 2, 44100, 3*time.Second), 2, 44100, 16, false)
+	for _, w := range Windows {
+		w := w
+		detectFrequency(t, wav, 440, func(f *FFT) { f.Window = w })
+	}
+
+	// Each window peaks in the middle and tapers towards the ends
+	for _, w := range Windows[1:] {
+		c := w.coefficients(nil, 64)
+		assert.InDelta(t, 1, c[32], 1e-3, w.String())
+		assert.Less(t, c[0], float32(0.1), w.String())
+	}
+}
+
+func TestFFTSize(t *testing.T) {
+	wav := encodeWAV(sine(440, 2, 44100, 3*time.Second), 2, 44100, 16, false)
+	detectFrequency(t, wav, 440, func(f *FFT) {
+		f.FrameSize = 2048
+		f.HopSize = 512
+		f.FFTSize = 8192
+	})
+	detectFrequency(t, wav, 440, func(f *FFT) {
+		f.FrameSize = 512
+		f.FFTSize = 4096
+	})
+}
+
+func TestFFTSpectrumAllocs(t *testing.T) {
+	f, err := NewFFT(&Config{Channels: 1, SampleRate: 44100, Format: F32})
+	assert.Nil(t, err)
+	f.Write(encodeF32(sine(440, 1, 44100, 100*time.Millisecond)))
+
+	// Once the scratch buffers have grown they're reused for every buffer
+	var a analysis
+	assert.True(t, f.fill(&a))
+	f.spectrum(&a)
+	assert.Equal(t, 0.0, testing.AllocsPerRun(10, func() { f.spectrum(&a) }))
+}
+
+func TestFFTOverlap(t *testing.T) {
+	f, err := NewFFT(&Config{Channels: 1, SampleRate: 44100, Format: F32})
+	assert.Nil(t, err)
+
+	ramp := make([]float64, 44100)
+	for i := range ramp {
+		ramp[i] = float64(i)
+	}
+	samples := encodeF32(ramp)
+	count := func(a *analysis) (n int, starts []float32) {
+		for f.fill(a) {
+			n++
+			starts = append(starts, a.buf[0])
+		}
+		return n, starts
+	}
+
+	// Frames which don't overlap start where the last one ended
+	f.Write(samples)
+	n, starts := count(&analysis{})
+	assert.Equal(t, 44100/1024, n)
+	assert.Equal(t, []float32{0, 1024, 2048}, starts[:3])
+
+	// Overlapping frames advance by the hop size
+	f.HopSize = 256
+	f.Write(samples)
+	n, starts = count(&analysis{})
+	assert.Equal(t, (44100-1024)/256+1, n)
+	assert.Equal(t, []float32{0, 256, 512}, starts[:3])
+}
+
+func TestFFTConfig(t *testing.T) {
+	_, err := NewFFT(&Config{Channels: 0, SampleRate: 44100})
+	assert.Equal(t, ErrChannelNum, err)
+
+	_, err = NewFFT(&Config{Channels: 2, SampleRate: 100})
+	assert.Equal(t, ErrSampleRate, err)
+
+	_, err = NewFFT(&Config{Channels: 2, SampleRate: 44100, Format: SampleFormat(10)})
+	assert.Equal(t, ErrSampleFormat, err)
+}
+
+func TestFFTIdle(t *testing.T) {
+	f, err := NewFFT(DefaultConfig())
+	assert.Nil(t, err)
+	defer runFFT(t, f)()
+	f.Stats()
+
+	// Without any audio the loop should sleep
+	time.Sleep(300 * time.Millisecond)
+	st := f.Stats()
+	assert.Equal(t, uint64(0), st.Buffers)
+	assert.Equal(t, 0.0, st.Load)
+
+	// Once a buffer's worth of audio has been written a colour is produced
+	f.Write(make([]byte, fftFrames*8))
+	select {
+	case <-f.Hues:
+	case <-time.After(time.Second):
+		t.Fatal("fft did not process the audio")
+	}
+	assert.Equal(t, uint64(1), f.Stats().Buffers)
+}
+
+func TestFFTSettings(t *testing.T) {
+	f, err := NewFFT(DefaultConfig())
+	assert.Nil(t, err)
+	defer runFFT(t, f)()
+
+	// The settings can be changed while the audio is analysed
+	samples := encodeF32(sine(440, 2, 44100, 100*time.Millisecond))
+	for _, m := range ColourMappings {
+		f.Lock()
+		f.Mapping = m
+		f.ChannelMode = Stereo
+		f.Smoothing.Target = SmoothColour
+		f.Curve = DefaultCurve()
+		f.Routes = DefaultRoutes()
+		f.Unlock()
+
+		f.Write(samples)
+		select {
+		case <-f.Hues:
+		case <-time.After(time.Second):
+			t.Fatal("fft did not process the audio")
+		}
+	}
+}
+
+func TestFFTLifecycle(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	f, err := NewFFT(DefaultConfig())
+	assert.Nil(t, err)
+
+	// FFT can be run repeatedly but only once at a time
+	for i := 0; i < 5; i++ {
+		stop := runFFT(t, f)
+		assert.Eventually(t, func() bool {
+			return f.Run(context.Background()) == ErrRunning
+		}, time.Second, 10*time.Millisecond)
+		stop()
+	}
+
+	// Run exits even if nothing is reading the colours
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	go func() {
+		for i := 0; i < 10; i++ {
+			f.Write(make([]byte, fftFrames*8))
+		}
+	}()
+	assert.Equal(t, context.DeadlineExceeded, f.Run(ctx))
+
+	assertNoLeaks(t, before)
+}
+
+// assertNoLeaks checks the number of goroutines returns to what it was before
+func assertNoLeaks(t *testing.T, before int) {
+	for deadline := time.Now().Add(time.Second); runtime.NumGoroutine() > before; {
+		if time.Now().After(deadline) {
+			t.Errorf("goroutines were leaked, %d before and %d after", before, runtime.NumGoroutine())
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
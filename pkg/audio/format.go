@@ -0,0 +1,97 @@
+package audio
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+
+	"github.com/gen2brain/malgo"
+)
+
+var ErrSampleFormat = errors.New("sample format is unsupported")
+
+// SampleFormat is the encoding of each sample in a frame, all
+// formats are little-endian
+type SampleFormat int
+
+const (
+	// F32 is a 32-bit float in the range [-1, 1]
+	F32 SampleFormat = iota
+	// S16 is a signed 16-bit integer
+	S16
+	// S24 is a signed 24-bit integer packed into 3 bytes
+	S24
+	// S32 is a signed 32-bit integer
+	S32
+	// U8 is an unsigned 8-bit integer centred on 128
+	U8
+)
+
+// Formats in order of preference when negotiating with a device
+var preferredFormats = []SampleFormat{F32, S32, S24, S16, U8}
+
+func (sf SampleFormat) String() string {
+	if !sf.Valid() {
+		return "Unknown"
+	}
+	return [...]string{"F32", "S16", "S24", "S32", "U8"}[sf]
+}
+
+// Valid returns whether the sample format is supported
+func (sf SampleFormat) Valid() bool {
+	return sf >= F32 && sf <= U8
+}
+
+// Size returns the number of bytes each sample takes up
+func (sf SampleFormat) Size() int {
+	return [...]int{4, 2, 3, 4, 1}[sf]
+}
+
+// Decode appends the samples in src to dst as floats in the range [-1, 1],
+// any trailing bytes which don't make up a whole sample are ignored
+func (sf SampleFormat) Decode(dst []float32, src []byte) []float32 {
+	size := sf.Size()
+	n := len(src) - len(src)%size
+
+	switch sf {
+	case F32:
+		for i := 0; i < n; i += size {
+			dst = append(dst, math.Float32frombits(binary.LittleEndian.Uint32(src[i:])))
+		}
+	case S16:
+		for i := 0; i < n; i += size {
+			dst = append(dst, float32(int16(binary.LittleEndian.Uint16(src[i:])))/(1<<15))
+		}
+	case S24:
+		for i := 0; i < n; i += size {
+			// Shift the sample into the top of an int32 so it's sign extended
+			v := int32(uint32(src[i])<<8|uint32(src[i+1])<<16|uint32(src[i+2])<<24) >> 8
+			dst = append(dst, float32(v)/(1<<23))
+		}
+	case S32:
+		for i := 0; i < n; i += size {
+			dst = append(dst, float32(int32(binary.LittleEndian.Uint32(src[i:])))/(1<<31))
+		}
+	case U8:
+		for i := 0; i < n; i += size {
+			dst = append(dst, (float32(src[i])-128)/128)
+		}
+	}
+
+	return dst
+}
+
+func (sf SampleFormat) malgo() malgo.FormatType {
+	return [...]malgo.FormatType{malgo.FormatF32, malgo.FormatS16, malgo.FormatS24, malgo.FormatS32, malgo.FormatU8}[sf]
+}
+
+// formatFromMalgo returns the SampleFormat equivalent to the malgo format
+// and whether one exists
+func formatFromMalgo(ft malgo.FormatType) (SampleFormat, bool) {
+	for _, sf := range preferredFormats {
+		if sf.malgo() == ft {
+			return sf, true
+		}
+	}
+	return 0, false
+}
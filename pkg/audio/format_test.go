@@ -0,0 +1,39 @@
+package audio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecode(t *testing.T) {
+	assert.Equal(t, []float32{-1, 0, 0.5}, S16.Decode(nil, []byte{0x00, 0x80, 0x00, 0x00, 0x00, 0x40}))
+	assert.Equal(t, []float32{-1, 0.5}, S24.Decode(nil, []byte{0x00, 0x00, 0x80, 0x00, 0x00, 0x40}))
+	assert.Equal(t, []float32{-1, 0, 0.5}, U8.Decode(nil, []byte{0x00, 0x80, 0xC0}))
+
+	// Trailing bytes which aren't a whole sample are ignored
+	assert.Equal(t, []float32{-1}, S32.Decode(nil, []byte{0x00, 0x00, 0x00, 0x80, 0xFF}))
+}
+
+func TestNegotiate(t *testing.T) {
+	conf := DefaultConfig()
+
+	// Devices which don't report their formats use the config's format
+	d := Device{}
+	assert.Equal(t, F32, d.Negotiate(conf).Format)
+
+	d.Formats = []SampleFormat{S16, F32}
+	assert.Equal(t, F32, d.Negotiate(conf).Format)
+
+	// Otherwise the most precise format is used
+	d.Formats = []SampleFormat{U8, S16, S24}
+	assert.Equal(t, S24, d.Negotiate(conf).Format)
+	assert.Equal(t, F32, conf.Format)
+
+	// Channels and sample rates are clamped to the device's ranges
+	d.MinChannels, d.MaxChannels = 1, 1
+	d.MinSampleRate, d.MaxSampleRate = 48000, 96000
+	n := d.Negotiate(conf)
+	assert.Equal(t, uint32(1), n.Channels)
+	assert.Equal(t, uint32(48000), n.SampleRate)
+}
@@ -0,0 +1,146 @@
+package audio
+
+import (
+	"errors"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+var ErrInvalidIdleEffect = errors.New("idle effect specified is invalid")
+
+const (
+	// How many times faster the colour fades back in than it fades out,
+	// so the first beat after a silence isn't lost
+	resumeSpeed = 4
+	// Period of the breathing effect
+	breathePeriod = 4 * time.Second
+	// How long the rainbow effect takes to cycle through the hues
+	rainbowPeriod = 20 * time.Second
+)
+
+// IdleEffect is what's displayed while the audio is silent
+type IdleEffect int
+
+const (
+	// FadeToBlack turns the colour off
+	FadeToBlack IdleEffect = iota
+	// IdleColour displays the gate's colour
+	IdleColour
+	// Breathe slowly pulses the gate's colour
+	Breathe
+	// Rainbow slowly cycles through the gradient, or the hues
+	Rainbow
+)
+
+// IdleEffects lists every idle effect in the order they're declared
+var IdleEffects = []IdleEffect{FadeToBlack, IdleColour, Breathe, Rainbow}
+
+func (ie IdleEffect) String() string {
+	return [...]string{"Black", "Colour", "Breathe", "Rainbow"}[ie]
+}
+
+// Gate describes the noise gate which fades the colour out when the audio
+// is silent and back in once sound returns
+type Gate struct {
+	// Whether the gate is used
+	Enabled bool
+	// Loudness in dBFS below which the audio is silent
+	Threshold float64
+	// How long the audio must be silent before the colour fades
+	Hold time.Duration
+	// How long the colour takes to fade to the idle effect
+	Fade time.Duration
+	// What's displayed while the audio is silent
+	Effect IdleEffect
+	// Colour used by the IdleColour and Breathe effects
+	Colour colorful.Color
+}
+
+// DefaultGate returns a gate which fades to black after two seconds of silence
+func DefaultGate() Gate {
+	return Gate{
+		Enabled:   true,
+		Threshold: -60,
+		Hold:      2 * time.Second,
+		Fade:      time.Second,
+		Effect:    FadeToBlack,
+		Colour:    colorful.Color{R: 0.2, G: 0.2, B: 0.4},
+	}
+}
+
+// gate fades c towards the idle effect depending on how long the audio,
+// which lasted for dt, has been silent
+func (f *FFT) gate(a *analysis, c colorful.Color, dt time.Duration, silent bool) colorful.Color {
+	g := f.Gate
+	if !g.Enabled {
+		a.silence, a.idle = 0, 0
+		return c
+	}
+
+	if silent {
+		a.silence += dt
+	} else {
+		a.silence = 0
+	}
+
+	// Fade out once the hold has passed and back in quicker
+	step := 1.0
+	if g.Fade > 0 {
+		step = float64(dt) / float64(g.Fade)
+	}
+	if a.silence >= g.Hold {
+		a.idle = math.Min(1, a.idle+step)
+	} else {
+		a.idle = math.Max(0, a.idle-step*resumeSpeed)
+	}
+
+	if a.idle == 0 {
+		return c
+	}
+	return c.BlendLab(f.idleColour(), a.idle).Clamped()
+}
+
+// idleColour returns the current colour of the idle effect
+func (f *FFT) idleColour() colorful.Color {
+	g := f.Gate
+	switch g.Effect {
+	case FadeToBlack:
+		return colorful.Color{}
+	case IdleColour:
+		return g.Colour
+	case Breathe:
+		phase := float64(time.Now().UnixNano()%int64(breathePeriod)) / float64(breathePeriod)
+		brightness := 0.6 - 0.4*math.Cos(2*math.Pi*phase)
+		h, s, v := g.Colour.Hsv()
+		return colorful.Hsv(h, s, v*brightness)
+	case Rainbow:
+		phase := float64(time.Now().UnixNano()%int64(rainbowPeriod)) / float64(rainbowPeriod)
+		if f.Gradient != nil {
+			return f.DrawMode.Interpolate(phase, *f.Gradient)
+		}
+		return colorful.Hsv(phase*360, 1, 1)
+	}
+
+	panic(ErrInvalidIdleEffect)
+}
+
+// stalled returns the colour to display if audio has stopped being written
+// to the FFT, in which case the audio is treated as silent. Nothing is
+// displayed if the audio was stopped deliberately
+func (f *FFT) stalled(a *analysis, period time.Duration) (colorful.Color, bool) {
+	if !f.Gate.Enabled || a.lastFrame.IsZero() || atomic.LoadInt32(&f.stopped) != 0 {
+		return colorful.Color{}, false
+	}
+
+	now := time.Now()
+	if now.Sub(a.lastFrame) < 2*period {
+		return colorful.Color{}, false
+	}
+
+	dt := now.Sub(a.gated)
+	a.gated = now
+	return f.gate(a, a.colour, dt, true), true
+}
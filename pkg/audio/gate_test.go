@@ -0,0 +1,77 @@
+package audio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lucasb-eyer/go-colorful"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGate(t *testing.T) {
+	f, err := NewFFT(DefaultConfig())
+	assert.Nil(t, err)
+	red := colorful.Color{R: 1}
+	dt := 10 * time.Millisecond
+
+	var a analysis
+	feed := func(silent bool, d time.Duration) (c colorful.Color) {
+		for i := time.Duration(0); i < d; i += dt {
+			c = f.gate(&a, red, dt, silent)
+		}
+		return c
+	}
+
+	// The colour is unchanged until the audio has been silent for the hold
+	assert.Equal(t, red, feed(false, time.Second))
+	assert.Equal(t, red, feed(true, f.Gate.Hold-dt))
+
+	// It then fades to black
+	faded := feed(true, f.Gate.Fade/2)
+	assert.True(t, faded.R > 0 && faded.R < 1)
+	assert.True(t, feed(true, f.Gate.Fade).AlmostEqualRgb(colorful.Color{}))
+
+	// And fades back in quicker once sound returns
+	assert.Equal(t, red, feed(false, f.Gate.Fade/resumeSpeed+dt))
+
+	// The idle colour can be used instead of black
+	f.Gate.Effect = IdleColour
+	assert.True(t, feed(true, f.Gate.Hold+f.Gate.Fade).AlmostEqualRgb(f.Gate.Colour))
+
+	// Disabled gates never fade
+	f.Gate.Enabled = false
+	assert.Equal(t, red, feed(true, f.Gate.Hold+f.Gate.Fade))
+}
+
+func TestGateStalled(t *testing.T) {
+	f, err := NewFFT(DefaultConfig())
+	assert.Nil(t, err)
+
+	// Nothing is displayed before any audio has been analysed
+	var a analysis
+	_, ok := f.stalled(&a, 250*time.Millisecond)
+	assert.False(t, ok)
+
+	// Or while audio is still arriving
+	a.colour = colorful.Color{G: 1}
+	a.lastFrame = time.Now()
+	a.gated = a.lastFrame
+	_, ok = f.stalled(&a, 250*time.Millisecond)
+	assert.False(t, ok)
+
+	// Once it stops the audio is treated as silent
+	a.lastFrame = time.Now().Add(-f.Gate.Hold - f.Gate.Fade)
+	a.gated = a.lastFrame
+	c, ok := f.stalled(&a, 250*time.Millisecond)
+	assert.True(t, ok)
+	assert.True(t, c.AlmostEqualRgb(colorful.Color{}))
+
+	// Unless it was stopped deliberately, until more audio is written
+	f.Gate.Effect = Rainbow
+	f.Stopped()
+	_, ok = f.stalled(&a, 250*time.Millisecond)
+	assert.False(t, ok)
+	f.Write(encodeF32(make([]float64, 16)))
+	_, ok = f.stalled(&a, 250*time.Millisecond)
+	assert.True(t, ok)
+}
@@ -0,0 +1,108 @@
+package audio
+
+import (
+	"math"
+	"time"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+const (
+	// Loudness is averaged over about this long, like momentary loudness
+	loudnessWindow = 400 * time.Millisecond
+	// Loudness of silence in dBFS, so the logarithm is never infinite
+	silentLoudness = -120
+)
+
+// Dynamics describes how the loudness of the audio changes the colour
+type Dynamics struct {
+	// Whether quieter audio makes the colour darker
+	Brightness bool
+	// Whether quieter audio makes the colour less saturated
+	Saturation bool
+	// Loudness in dBFS at and below which the colour is black or grey
+	Floor float64
+	// Loudness in dBFS at and above which the colour is unchanged
+	Ceiling float64
+	// Exponent applied to the level between the floor and ceiling,
+	// above 1 quiet audio is darker and below 1 it's brighter
+	Curve float64
+	// Whether the floor and ceiling are ignored in favour of the
+	// range the AGC has recently seen the loudness in
+	Adaptive bool
+}
+
+// DefaultDynamics returns dynamics which leave the colour unchanged
+// until Brightness or Saturation are enabled
+func DefaultDynamics() Dynamics {
+	return Dynamics{
+		Floor:   -50,
+		Ceiling: -6,
+		Curve:   1,
+	}
+}
+
+// Level returns where the loudness lies between the floor and
+// ceiling after the curve is applied, from 0 to 1
+func (d Dynamics) Level(loudness float64) float64 {
+	if d.Ceiling <= d.Floor {
+		if loudness >= d.Ceiling {
+			return 1
+		}
+		return 0
+	}
+
+	return d.Shape((loudness - d.Floor) / (d.Ceiling - d.Floor))
+}
+
+// Shape clamps the level between 0 and 1 and applies the curve to it
+func (d Dynamics) Shape(level float64) float64 {
+	level = math.Max(0, math.Min(1, level))
+	if d.Curve > 0 {
+		level = math.Pow(level, d.Curve)
+	}
+	return level
+}
+
+// Apply darkens or desaturates the colour by the level
+func (d Dynamics) Apply(c colorful.Color, level float64) colorful.Color {
+	if !d.Brightness && !d.Saturation {
+		return c
+	}
+
+	h, s, v := c.Hsv()
+	if d.Brightness {
+		v *= level
+	}
+	if d.Saturation {
+		s *= level
+	}
+	return colorful.Hsv(h, s, v)
+}
+
+// loudness updates the loudness of the audio in dBFS, the mean square of
+// the samples is averaged over the loudness window so it doesn't flicker
+func (f *FFT) loudness(a *analysis) {
+	var sum float64
+	for _, s := range a.samples {
+		sum += float64(s) * float64(s)
+	}
+	ms := sum / float64(len(a.samples))
+
+	// A new frame replaces part of the average depending on its duration
+	alpha := smoothing(a.hopDuration, loudnessWindow)
+	if a.meanSquare == 0 {
+		alpha = 1
+	}
+	a.meanSquare += alpha * (ms - a.meanSquare)
+	a.loudness = decibels(a.meanSquare)
+	a.loudnessLevel = a.loudnessAGC.normalise(a.loudness, a.hopDuration, f.AGC)
+}
+
+// level returns the level of the loudness according to the dynamics
+func (f *FFT) level(a *analysis) float64 {
+	if f.Dynamics.Adaptive {
+		return f.Dynamics.Shape(a.loudnessLevel)
+	}
+	return f.Dynamics.Level(a.loudness)
+}
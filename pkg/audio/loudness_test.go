@@ -0,0 +1,55 @@
+package audio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lucasb-eyer/go-colorful"
+	"github.com/stretchr/testify/assert"
+)
+
+// analyseLoudness returns the loudness after all of the samples are analysed
+func analyseLoudness(t *testing.T, f *FFT, samples []float64) float64 {
+	f.Write(encodeF32(samples))
+
+	var a analysis
+	for f.fill(&a) {
+		f.spectrum(&a)
+		f.onset(&a)
+		f.loudness(&a)
+	}
+	return a.loudness
+}
+
+func TestLoudness(t *testing.T) {
+	f, err := NewFFT(&Config{Channels: 1, SampleRate: 44100, Format: F32})
+	assert.Nil(t, err)
+
+	// A sine with an amplitude of 0.5 has an RMS of -9 dBFS
+	assert.InDelta(t, -9.03, analyseLoudness(t, f, sine(440, 1, 44100, time.Second)), 0.1)
+	assert.Equal(t, float64(silentLoudness), analyseLoudness(t, f, make([]float64, 44100)))
+}
+
+func TestDynamics(t *testing.T) {
+	d := Dynamics{Floor: -60, Ceiling: 0, Curve: 1}
+	assert.Equal(t, 0.0, d.Level(-80))
+	assert.Equal(t, 0.5, d.Level(-30))
+	assert.Equal(t, 1.0, d.Level(6))
+
+	d.Curve = 2
+	assert.Equal(t, 0.25, d.Level(-30))
+
+	// The colour is unchanged until brightness or saturation are enabled
+	red := colorful.Color{R: 1}
+	assert.Equal(t, red, d.Apply(red, 0.5))
+
+	d.Brightness = true
+	_, s, v := d.Apply(red, 0.5).Hsv()
+	assert.InDelta(t, 1, s, 1e-9)
+	assert.InDelta(t, 0.5, v, 1e-9)
+
+	d.Brightness, d.Saturation = false, true
+	_, s, v = d.Apply(red, 0.25).Hsv()
+	assert.InDelta(t, 0.25, s, 1e-9)
+	assert.InDelta(t, 1, v, 1e-9)
+}
@@ -0,0 +1,87 @@
+package audio
+
+import (
+	"errors"
+	"math"
+
+	"currents/internal/dsp"
+)
+
+var ErrInvalidPitchMode = errors.New("pitch mode specified is invalid")
+
+// Aperiodicity below which YIN accepts a period as the pitch
+const yinThreshold = 0.15
+
+// PitchMode is how the frequency which is visualised is estimated
+type PitchMode int
+
+const (
+	// PeakBin uses the centre frequency of the loudest bin of the spectrum,
+	// so the frequency can only be as precise as the bins are wide
+	PeakBin PitchMode = iota
+	// Parabolic fits a parabola through the loudest bin and its neighbours
+	// to estimate the frequency between bins
+	Parabolic
+	// YIN estimates the fundamental frequency from the audio itself, which
+	// isn't fooled by loud harmonics, falling back to Parabolic if the audio
+	// isn't periodic. Frames must hold two periods of the lowest frequency
+	YIN
+)
+
+// PitchModes lists every pitch mode in the order they're declared
+var PitchModes = []PitchMode{PeakBin, Parabolic, YIN}
+
+func (pm PitchMode) String() string {
+	return [...]string{"Peak bin", "Parabolic", "YIN"}[pm]
+}
+
+// pitch estimates the frequency of the buffer with the pitch mode
+func (f *FFT) pitch(a *analysis) float64 {
+	switch f.PitchMode {
+	case PeakBin:
+		return a.binSize * float64(peakBin(a.tonal))
+	case Parabolic:
+		return a.binSize * parabolicPeak(a.tonal)
+	case YIN:
+		var freq float64
+		freq, a.differences = dsp.YIN(a.differences, a.samples, float64(a.analysisRate), yinThreshold)
+		if freq > 0 {
+			return freq
+		}
+		return a.binSize * parabolicPeak(a.tonal)
+	}
+
+	panic(ErrInvalidPitchMode)
+}
+
+// peakBin returns the index of the largest magnitude
+func peakBin(magnitudes []float64) int {
+	var max float64
+	var index int
+	for i, m := range magnitudes {
+		if m > max {
+			max = m
+			index = i
+		}
+	}
+	return index
+}
+
+// parabolicPeak returns the fractional index of the peak of the parabola
+// through the largest magnitude and its neighbours. Log magnitudes are
+// used because the peak of a windowed sine is close to a parabola in them
+func parabolicPeak(magnitudes []float64) float64 {
+	k := peakBin(magnitudes)
+	if k == 0 || k == len(magnitudes)-1 {
+		return float64(k)
+	}
+
+	const floor = 1e-12
+	l := math.Log(magnitudes[k-1] + floor)
+	c := math.Log(magnitudes[k] + floor)
+	r := math.Log(magnitudes[k+1] + floor)
+	if l-2*c+r == 0 {
+		return float64(k)
+	}
+	return float64(k) + 0.5*(l-r)/(l-2*c+r)
+}
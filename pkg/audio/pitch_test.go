@@ -0,0 +1,59 @@
+package audio
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// analysePitch returns the pitch of the first buffer of the samples
+func analysePitch(t *testing.T, f *FFT, samples []float64) float64 {
+	f.ring.Reset()
+	f.Write(encodeF32(samples))
+
+	var a analysis
+	assert.True(t, f.fill(&a))
+	f.spectrum(&a)
+	return f.pitch(&a)
+}
+
+// harmonics returns a tone whose harmonics are louder than its fundamental
+func harmonics(freq float64, sampleRate int, d time.Duration) []float64 {
+	samples := make([]float64, int(d.Seconds()*float64(sampleRate)))
+	for i := range samples {
+		t := float64(i) / float64(sampleRate)
+		samples[i] = 0.1*math.Sin(2*math.Pi*freq*t) + 0.3*math.Sin(4*math.Pi*freq*t) + 0.3*math.Sin(6*math.Pi*freq*t)
+	}
+	return samples
+}
+
+func TestPitch(t *testing.T) {
+	f, err := NewFFT(&Config{Channels: 1, SampleRate: 44100, Format: F32})
+	assert.Nil(t, err)
+
+	// Bass notes only a few Hz apart are distinguished between bins
+	for _, freq := range []float64{41.2, 43.65, 55, 61.7, 110} {
+		f.FrameSize = 4096
+		f.PitchMode = PeakBin
+		peak := analysePitch(t, f, sine(freq, 1, 44100, 200*time.Millisecond))
+		assert.InDelta(t, freq, peak, 44100.0/4096/2+1e-9)
+
+		f.PitchMode = Parabolic
+		assert.InDelta(t, freq, analysePitch(t, f, sine(freq, 1, 44100, 200*time.Millisecond)), 0.5)
+
+		f.PitchMode = YIN
+		assert.InDelta(t, freq, analysePitch(t, f, sine(freq, 1, 44100, 200*time.Millisecond)), 0.5)
+	}
+
+	// YIN finds the fundamental even when its harmonics are louder
+	f.FrameSize = 2048
+	f.PitchMode = Parabolic
+	assert.NotEqual(t, 110.0, math.Round(analysePitch(t, f, harmonics(110, 44100, 100*time.Millisecond))))
+	f.PitchMode = YIN
+	assert.InDelta(t, 110, analysePitch(t, f, harmonics(110, 44100, 100*time.Millisecond)), 0.5)
+
+	// YIN falls back to the spectrum without a pitch
+	assert.Equal(t, 0.0, analysePitch(t, f, make([]float64, 4096)))
+}
@@ -0,0 +1,68 @@
+package audio
+
+import (
+	"io"
+)
+
+// How many frames the ReaderSource reads before writing them out
+const readerChunkFrames = 512
+
+// ReaderSource is a Source which streams raw interleaved frames from a
+// reader, e.g. a pipe or network connection. The frames must be laid out
+// as described by the source's Config
+type ReaderSource struct {
+	streamer
+
+	r    io.Reader
+	conf Config
+}
+
+// NewReaderSource creates a source which reads frames in the given format from r
+func NewReaderSource(r io.Reader, conf *Config) *ReaderSource {
+	return &ReaderSource{
+		r:    r,
+		conf: *conf,
+	}
+}
+
+func (rs *ReaderSource) Format() Config {
+	return rs.conf
+}
+
+func (rs *ReaderSource) Start(w io.Writer, done func(error)) error {
+	rs.m.Lock()
+	defer rs.m.Unlock()
+
+	// The old goroutine mustn't still be reading once the new one starts
+	rs.halted()
+	rs.start(func(stop chan struct{}) { rs.stream(w, done, stop) })
+	return nil
+}
+
+func (rs *ReaderSource) stream(w io.Writer, done func(error), stop chan struct{}) {
+	frameSize := int(rs.conf.Channels) * rs.conf.Format.Size()
+	buf := make([]byte, frameSize*readerChunkFrames)
+
+	for {
+		// Only whole frames are written, a trailing partial frame is discarded
+		n, err := io.ReadFull(rs.r, buf)
+		n -= n % frameSize
+		if n > 0 {
+			running, werr := rs.write(w, buf[:n], n/frameSize, stop)
+			if !running {
+				return
+			} else if werr != nil {
+				finish(done, stop, werr)
+				return
+			}
+		}
+
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			finish(done, stop, nil)
+			return
+		} else if err != nil {
+			finish(done, stop, err)
+			return
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package audio
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// slowReader reads a little at a time, so a source streaming it is still
+// running when it's restarted
+type slowReader struct {
+	r io.Reader
+}
+
+func (sr slowReader) Read(p []byte) (int, error) {
+	time.Sleep(time.Millisecond)
+	if len(p) > 256 {
+		p = p[:256]
+	}
+	return sr.r.Read(p)
+}
+
+// notifyWriter closes wrote once something has been written to it
+type notifyWriter struct {
+	bytes.Buffer
+	wrote chan struct{}
+}
+
+func (nw *notifyWriter) Write(p []byte) (int, error) {
+	if nw.Len() == 0 {
+		defer close(nw.wrote)
+	}
+	return nw.Buffer.Write(p)
+}
+
+func TestReaderSourceRestart(t *testing.T) {
+	data := make([]byte, 8*4096)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	rs := NewReaderSource(slowReader{bytes.NewReader(data)}, &Config{Channels: 2, SampleRate: 44100})
+
+	first, rest := &notifyWriter{wrote: make(chan struct{})}, bytes.NewBuffer(nil)
+	assert.Nil(t, rs.Start(first, func(error) {}))
+	<-first.wrote
+
+	// Restarting carries on from where the old goroutine stopped reading
+	done := make(chan error, 1)
+	assert.Nil(t, rs.Start(rest, func(err error) { done <- err }))
+	select {
+	case err := <-done:
+		assert.Nil(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("source did not finish")
+	}
+	assert.NotEmpty(t, first.Bytes())
+	assert.True(t, bytes.HasPrefix(data, first.Bytes()))
+	assert.True(t, bytes.HasSuffix(data, rest.Bytes()))
+	assert.Equal(t, uint64(rest.Len()/8), rs.Frames())
+}
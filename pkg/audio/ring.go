@@ -0,0 +1,132 @@
+package audio
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// ring is a fixed capacity single-producer/single-consumer buffer of
+// interleaved samples. When the buffer is full the oldest frames are
+// dropped to make room for new ones. Samples are stored as their bits
+// and accessed atomically so the producer and consumer never race, even
+// when the producer overwrites frames the consumer is reading, in which
+// case the consumer notices the frames were dropped and retries
+type ring struct {
+	// Indexes only ever increase, they're masked to get the position in
+	// data. They must be accessed atomically and are kept first for alignment
+	read    uint64
+	write   uint64
+	dropped uint64 // Samples dropped because the buffer was full
+
+	data []uint32
+	mask uint64
+}
+
+// newRing creates a ring which holds at least size samples
+func newRing(size int) *ring {
+	size = nextPowerOf2(size)
+	return &ring{
+		data: make([]uint32, size),
+		mask: uint64(size - 1),
+	}
+}
+
+func nextPowerOf2(x int) int {
+	n := 1
+	for n < x {
+		n <<= 1
+	}
+	return n
+}
+
+// Write adds the samples to the buffer, both the samples and frame, the
+// number of samples in each frame, must hold a whole number of frames.
+// It never blocks or allocates and must only be called by the producer
+func (r *ring) Write(samples []float32, frame int) {
+	size := uint64(len(r.data))
+
+	// Only the newest samples which fit can be kept
+	if fit := int(size) - int(size)%frame; len(samples) > fit {
+		atomic.AddUint64(&r.dropped, uint64(len(samples)-fit))
+		samples = samples[len(samples)-fit:]
+	}
+	n := uint64(len(samples))
+	w := atomic.LoadUint64(&r.write)
+
+	// Make room by dropping the oldest frames
+	for {
+		rd := atomic.LoadUint64(&r.read)
+		if w+n-rd <= size {
+			break
+		}
+
+		drop := w + n - size - rd
+		if rem := drop % uint64(frame); rem != 0 {
+			drop += uint64(frame) - rem
+		}
+		if atomic.CompareAndSwapUint64(&r.read, rd, rd+drop) {
+			atomic.AddUint64(&r.dropped, drop)
+			break
+		}
+	}
+
+	for i, s := range samples {
+		atomic.StoreUint32(&r.data[(w+uint64(i))&r.mask], math.Float32bits(s))
+	}
+	atomic.StoreUint64(&r.write, w+n)
+}
+
+// Read moves as many samples as are available, up to len(dst), into dst
+// and returns how many were read. len(dst) must be a whole number of frames.
+// It never blocks and must only be called by the consumer
+func (r *ring) Read(dst []float32) int {
+	for {
+		rd := atomic.LoadUint64(&r.read)
+		w := atomic.LoadUint64(&r.write)
+
+		// The read index can briefly pass the write index while
+		// the producer is dropping frames to make room
+		var n uint64
+		if w > rd {
+			n = w - rd
+		}
+		if n > uint64(len(dst)) {
+			n = uint64(len(dst))
+		}
+		for i := uint64(0); i < n; i++ {
+			dst[i] = math.Float32frombits(atomic.LoadUint32(&r.data[(rd+i)&r.mask]))
+		}
+
+		// If the producer dropped the frames while we were reading them
+		// then they may have been overwritten, so we try again
+		if atomic.CompareAndSwapUint64(&r.read, rd, rd+n) {
+			return int(n)
+		}
+	}
+}
+
+// Len returns the number of samples waiting to be read
+func (r *ring) Len() int {
+	rd := atomic.LoadUint64(&r.read)
+	w := atomic.LoadUint64(&r.write)
+	if w < rd {
+		return 0
+	}
+	return int(w - rd)
+}
+
+// Reset discards every sample in the buffer
+func (r *ring) Reset() {
+	for {
+		rd := atomic.LoadUint64(&r.read)
+		w := atomic.LoadUint64(&r.write)
+		if w < rd || atomic.CompareAndSwapUint64(&r.read, rd, w) {
+			return
+		}
+	}
+}
+
+// Dropped returns how many samples have been dropped because the buffer was full
+func (r *ring) Dropped() uint64 {
+	return atomic.LoadUint64(&r.dropped)
+}
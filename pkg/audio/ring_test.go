@@ -0,0 +1,88 @@
+package audio
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRingDropsOldest(t *testing.T) {
+	r := newRing(8)
+	r.Write([]float32{1, 1, 2, 2, 3, 3}, 2)
+	r.Write([]float32{4, 4, 5, 5}, 2)
+	assert.Equal(t, 8, r.Len())
+	assert.Equal(t, uint64(2), r.Dropped())
+
+	dst := make([]float32, 4)
+	assert.Equal(t, 4, r.Read(dst))
+	assert.Equal(t, []float32{2, 2, 3, 3}, dst)
+
+	// Writes bigger than the buffer only keep the newest frames
+	r.Write([]float32{6, 6, 7, 7, 8, 8, 9, 9, 10, 10}, 2)
+	assert.Equal(t, 8, r.Read(make([]float32, 8)))
+
+	r.Write([]float32{1, 2, 3}, 3)
+	r.Reset()
+	assert.Equal(t, 0, r.Len())
+	assert.Equal(t, 0, r.Read(dst))
+}
+
+func TestRingFrameAlignment(t *testing.T) {
+	// Dropping to make room must never split a frame
+	r := newRing(8)
+	r.Write([]float32{1, 1, 1, 2, 2, 2}, 3)
+	r.Write([]float32{3, 3, 3}, 3)
+
+	dst := make([]float32, 6)
+	assert.Equal(t, 6, r.Read(dst))
+	assert.Equal(t, []float32{2, 2, 2, 3, 3, 3}, dst)
+}
+
+func TestRingConcurrent(t *testing.T) {
+	const total = 1 << 20
+	r := newRing(1024)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		chunk := make([]float32, 64)
+		for i := 0; i < total; i += len(chunk) {
+			for j := range chunk {
+				chunk[j] = float32(i + j)
+			}
+			r.Write(chunk, 1)
+		}
+	}()
+
+	// Samples may be dropped but those which are read must be in order
+	read := 0
+	last := float32(-1)
+	dst := make([]float32, 100)
+	for last < total-1 {
+		n := r.Read(dst)
+		for _, s := range dst[:n] {
+			if s <= last {
+				t.Fatalf("sample %v read after %v", s, last)
+			}
+			last = s
+		}
+		read += n
+	}
+	wg.Wait()
+
+	assert.Equal(t, total, read+int(r.Dropped()))
+}
+
+func TestFFTWriteAllocs(t *testing.T) {
+	f, err := NewFFT(&Config{Channels: 2, SampleRate: 44100, Format: S24})
+	assert.Nil(t, err)
+
+	// Writes which split frames must still not allocate
+	p := make([]byte, 6*512+4)
+	allocs := testing.AllocsPerRun(100, func() {
+		f.Write(p)
+	})
+	assert.Equal(t, 0.0, allocs)
+}
@@ -0,0 +1,219 @@
+package audio
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+var (
+	ErrInvalidFeature   = errors.New("feature specified is invalid")
+	ErrInvalidParameter = errors.New("colour parameter specified is invalid")
+)
+
+// Feature is a feature of the audio which can be routed to a colour
+// parameter, every feature is normalised from 0 to 1
+type Feature int
+
+const (
+	// FrequencyFeature is what the FrequencyRamp maps onto the gradient,
+	// the position of the frequency estimated by the PitchMode or the
+	// Descriptor, or the level of the Descriptor if it isn't a frequency
+	FrequencyFeature Feature = iota
+	// LoudnessFeature is the loudness level used by the Dynamics
+	LoudnessFeature
+	// BandFeature is the level of the energy in one of the Bands
+	BandFeature
+	// CentroidFeature is the centroid's position on the scale
+	CentroidFeature
+	// FluxFeature is the spectral flux normalised by the AGC
+	FluxFeature
+	// FlatnessFeature is the spectral flatness
+	FlatnessFeature
+	// BeatPhaseFeature is how far through the current beat the audio is
+	BeatPhaseFeature
+)
+
+// Features lists every feature in the order they're declared
+var Features = []Feature{FrequencyFeature, LoudnessFeature, BandFeature, CentroidFeature, FluxFeature, FlatnessFeature, BeatPhaseFeature}
+
+var featureNames = [...]string{"Frequency", "Loudness", "Band energy", "Centroid", "Flux", "Flatness", "Beat phase"}
+
+func (ft Feature) String() string {
+	return featureNames[ft]
+}
+
+// MarshalText lets features be saved by name
+func (ft Feature) MarshalText() ([]byte, error) {
+	if ft < 0 || int(ft) >= len(featureNames) {
+		return nil, ErrInvalidFeature
+	}
+	return []byte(ft.String()), nil
+}
+
+func (ft *Feature) UnmarshalText(text []byte) error {
+	for _, f := range Features {
+		if f.String() == string(text) {
+			*ft = f
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %q", ErrInvalidFeature, text)
+}
+
+// Parameter is a parameter of the colour which features can be routed to
+type Parameter int
+
+const (
+	// GradientPosition is the position on the gradient, or the hues
+	GradientPosition Parameter = iota
+	// HueOffset rotates the hue, 1 is a full turn
+	HueOffset
+	// Saturation scales the saturation of the colour, which is
+	// left alone if nothing is routed to it
+	Saturation
+	// Brightness scales the brightness of the colour, which is
+	// left alone if nothing is routed to it
+	Brightness
+)
+
+// Parameters lists every parameter in the order they're declared
+var Parameters = []Parameter{GradientPosition, HueOffset, Saturation, Brightness}
+
+var parameterNames = [...]string{"Position", "Hue offset", "Saturation", "Brightness"}
+
+func (p Parameter) String() string {
+	return parameterNames[p]
+}
+
+// MarshalText lets parameters be saved by name
+func (p Parameter) MarshalText() ([]byte, error) {
+	if p < 0 || int(p) >= len(parameterNames) {
+		return nil, ErrInvalidParameter
+	}
+	return []byte(p.String()), nil
+}
+
+func (p *Parameter) UnmarshalText(text []byte) error {
+	for _, param := range Parameters {
+		if param.String() == string(text) {
+			*p = param
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %q", ErrInvalidParameter, text)
+}
+
+// Route connects a feature to a colour parameter. The feature, which is
+// inverted first if needed, is multiplied by Scale and added to Offset.
+// Routes to the same parameter are added together
+type Route struct {
+	Feature Feature `json:"feature"`
+	// Name of the band used by BandFeature
+	Band      string    `json:"band,omitempty"`
+	Parameter Parameter `json:"parameter"`
+	Scale     float64   `json:"scale"`
+	Offset    float64   `json:"offset"`
+	Invert    bool      `json:"invert"`
+}
+
+// Routes are the routes used by the Routed mapping
+type Routes []Route
+
+// DefaultRoutes routes the frequency to the position on the gradient,
+// which is the same as the FrequencyRamp mapping
+func DefaultRoutes() Routes {
+	return Routes{{Feature: FrequencyFeature, Parameter: GradientPosition, Scale: 1}}
+}
+
+// Apply returns the value the route gives the feature's value x
+func (r Route) Apply(x float64) float64 {
+	if r.Invert {
+		x = 1 - x
+	}
+	return r.Offset + r.Scale*x
+}
+
+// feature returns the value of the feature from 0 to 1, ramp is the
+// value of the FrequencyFeature
+func (f *FFT) feature(a *analysis, r Route, ramp float64) float64 {
+	switch r.Feature {
+	case FrequencyFeature:
+		return ramp
+	case LoudnessFeature:
+		return f.level(a)
+	case BandFeature:
+		for _, e := range a.energies {
+			if e.Name == r.Band {
+				return e.Level
+			}
+		}
+		return 0
+	case CentroidFeature:
+		return f.position(math.Min(a.descriptors.Centroid, f.MaxFreq))
+	case FluxFeature:
+		return a.fluxLevel
+	case FlatnessFeature:
+		return a.descriptors.Flatness
+	case BeatPhaseFeature:
+		if a.lastBeat.IsZero() {
+			return 0
+		}
+		period := f.Tempo().Period()
+		if period == 0 {
+			period = time.Duration(float64(time.Minute) / defaultBPM)
+		}
+		return math.Mod(float64(time.Since(a.lastBeat))/float64(period), 1)
+	}
+	panic(ErrInvalidFeature)
+}
+
+// route creates the colour from the features routed to each parameter
+func (f *FFT) route(a *analysis) colorful.Color {
+	// The frequency is smoothed the same as it is by the FrequencyRamp,
+	// once however many routes use it
+	ramp := f.rampPosition(a)
+
+	var pos, hue float64
+	saturation, brightness := 1.0, 1.0
+	var saturated, brightened bool
+	for _, r := range f.Routes {
+		x := r.Apply(f.feature(a, r, ramp))
+		switch r.Parameter {
+		case GradientPosition:
+			pos += x
+		case HueOffset:
+			hue += x
+		case Saturation:
+			if !saturated {
+				saturation, saturated = 0, true
+			}
+			saturation += x
+		case Brightness:
+			if !brightened {
+				brightness, brightened = 0, true
+			}
+			brightness += x
+		default:
+			panic(ErrInvalidParameter)
+		}
+	}
+
+	pos = math.Max(0, math.Min(1, pos))
+	c := colorful.Hsv(pos*f.TotalHues, 1, 1)
+	if f.Gradient != nil {
+		c = f.DrawMode.Interpolate(pos, *f.Gradient)
+	}
+
+	h, s, v := c.Hsv()
+	h = math.Mod(h+hue*360, 360)
+	if h < 0 {
+		h += 360
+	}
+	s *= math.Max(0, math.Min(1, saturation))
+	v *= math.Max(0, math.Min(1, brightness))
+	return colorful.Hsv(h, s, v)
+}
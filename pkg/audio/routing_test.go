@@ -0,0 +1,94 @@
+package audio
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/lucasb-eyer/go-colorful"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoutesJSON(t *testing.T) {
+	routes := Routes{
+		{Feature: BandFeature, Band: "Bass", Parameter: Brightness, Scale: 0.5, Offset: 0.5, Invert: true},
+		{Feature: BeatPhaseFeature, Parameter: HueOffset, Scale: 1},
+	}
+
+	// Features and parameters are saved by name
+	data, err := json.Marshal(routes)
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), `"feature":"Band energy"`)
+	assert.Contains(t, string(data), `"parameter":"Hue offset"`)
+
+	var loaded Routes
+	assert.Nil(t, json.Unmarshal(data, &loaded))
+	assert.Equal(t, routes, loaded)
+
+	err = json.Unmarshal([]byte(`[{"feature":"Colour","parameter":"Position"}]`), &loaded)
+	assert.ErrorIs(t, err, ErrInvalidFeature)
+	err = json.Unmarshal([]byte(`[{"feature":"Flux","parameter":"Size"}]`), &loaded)
+	assert.ErrorIs(t, err, ErrInvalidParameter)
+}
+
+func TestRouteApply(t *testing.T) {
+	r := Route{Scale: 0.5, Offset: 0.25}
+	assert.Equal(t, 0.25, r.Apply(0))
+	assert.Equal(t, 0.75, r.Apply(1))
+
+	r.Invert = true
+	assert.Equal(t, 0.75, r.Apply(0))
+	assert.Equal(t, 0.25, r.Apply(1))
+}
+
+func TestRouted(t *testing.T) {
+	f, err := NewFFT(&Config{Channels: 1, SampleRate: 44100, Format: F32})
+	assert.Nil(t, err)
+
+	var a analysis
+	a.frequency = 440
+	a.loudness = 0.5
+
+	// The default routes give the same colour as the frequency ramp
+	want := f.colour(&a)
+	f.Mapping = Routed
+	assert.True(t, want.AlmostEqualRgb(f.colour(&a)))
+
+	// The frequency is smoothed the same way too
+	f.Smoothing = Smoothing{Filter: SlewLimiter, Target: SmoothFrequency, Rate: 1}
+	ramp, routed := analysis{hopDuration: 10 * time.Millisecond}, analysis{hopDuration: 10 * time.Millisecond}
+	for _, freq := range []float64{100, 2000, 2000} {
+		ramp.frequency, routed.frequency = freq, freq
+		f.Mapping = FrequencyRamp
+		c := f.colour(&ramp)
+		f.Mapping = Routed
+		assert.True(t, c.AlmostEqualRgb(f.colour(&routed)))
+	}
+	assert.False(t, f.colour(&routed).AlmostEqualRgb(colorful.Hsv(f.position(2000)*f.TotalHues, 1, 1)))
+	f.Smoothing.Filter = NoSmoothing
+
+	// Inverting the frequency moves to the other end of the gradient
+	f.Routes[0].Invert = true
+	h, _, _ := f.colour(&a).Hsv()
+	assert.InDelta(t, (1-f.position(440))*f.TotalHues, h, 1e-6)
+	f.Routes[0].Invert = false
+
+	// Half a turn of hue offset rotates the hue
+	f.Routes = append(DefaultRoutes(), Route{Feature: FrequencyFeature, Parameter: HueOffset, Offset: 0.5})
+	wantH, _, _ := want.Hsv()
+	h, _, _ = f.colour(&a).Hsv()
+	assert.InDelta(t, wantH+180, h, 1e-6)
+
+	// Brightness follows the loudness once it's routed
+	f.Routes = append(DefaultRoutes(), Route{Feature: LoudnessFeature, Parameter: Brightness, Scale: 1})
+	_, _, v := f.colour(&a).Hsv()
+	assert.InDelta(t, f.level(&a), v, 1e-6)
+
+	// Saturation is clamped, so it can be switched off
+	f.Routes = append(DefaultRoutes(), Route{Feature: FlatnessFeature, Parameter: Saturation, Offset: -1})
+	assert.True(t, colorful.Hsv(0, 0, 1).AlmostEqualRgb(f.colour(&a)))
+
+	// Missing bands are silent
+	f.Routes = Routes{{Feature: BandFeature, Band: "Missing", Parameter: GradientPosition, Scale: 1}}
+	assert.True(t, colorful.Hsv(0, 1, 1).AlmostEqualRgb(f.colour(&a)))
+}
@@ -0,0 +1,60 @@
+package audio
+
+import (
+	"errors"
+	"math"
+
+	"currents/internal/dsp"
+)
+
+var ErrInvalidScale = errors.New("frequency scale specified is invalid")
+
+// FrequencyScale is how a frequency is converted into a position on the
+// gradient, or the hues
+type FrequencyScale int
+
+const (
+	// CurveScale follows the control points of the FFT's Curve
+	CurveScale FrequencyScale = iota
+	// LogScale gives each octave between MinFreq and MaxFreq the same space
+	LogScale
+	// MelScale spaces frequencies between MinFreq and MaxFreq by how far
+	// apart their pitches sound
+	MelScale
+	// BarkScale spaces frequencies between MinFreq and MaxFreq by the
+	// critical bands of hearing
+	BarkScale
+)
+
+// FrequencyScales lists every scale in the order they're declared
+var FrequencyScales = []FrequencyScale{CurveScale, LogScale, MelScale, BarkScale}
+
+func (fs FrequencyScale) String() string {
+	return [...]string{"Curve", "Log", "Mel", "Bark"}[fs]
+}
+
+// position returns where the frequency lies on the scale from 0 to 1
+func (f *FFT) position(freq float64) float64 {
+	if f.Scale == CurveScale {
+		return f.Curve.Position(freq)
+	}
+
+	min, max := f.MinFreq, f.MaxFreq
+	if min <= 0 || max <= min {
+		return 0
+	}
+	freq = math.Max(min, math.Min(max, freq))
+
+	var convert func(float64) float64
+	switch f.Scale {
+	case LogScale:
+		convert = math.Log2
+	case MelScale:
+		convert = dsp.HzToMel
+	case BarkScale:
+		convert = dsp.HzToBark
+	default:
+		panic(ErrInvalidScale)
+	}
+	return (convert(freq) - convert(min)) / (convert(max) - convert(min))
+}
@@ -0,0 +1,45 @@
+package audio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScale(t *testing.T) {
+	f, err := NewFFT(DefaultConfig())
+	assert.Nil(t, err)
+
+	// The default curve is the same as the old ramp below its knee
+	assert.InDelta(t, 600.0/1200*310/320, f.position(600), 1e-9)
+
+	// Each octave has the same space on the log scale
+	f.Scale = LogScale
+	f.MinFreq, f.MaxFreq = 20, 2560
+	assert.InDelta(t, 1.0/7, f.position(40), 1e-9)
+	assert.InDelta(t, 3.0/7, f.position(160), 1e-9)
+	assert.Equal(t, 0.0, f.position(0))
+	assert.Equal(t, 1.0, f.position(20000))
+
+	// Every scale spans the range and increases with frequency
+	for _, s := range FrequencyScales[1:] {
+		f.Scale = s
+		assert.InDelta(t, 0, f.position(f.MinFreq), 1e-9, s.String())
+		assert.InDelta(t, 1, f.position(f.MaxFreq), 1e-9, s.String())
+
+		last := -1.0
+		for freq := f.MinFreq; freq <= f.MaxFreq; freq += 10 {
+			pos := f.position(freq)
+			assert.Greater(t, pos, last, s.String())
+			last = pos
+		}
+	}
+
+	// Mel and Bark give low frequencies less space than the log scale
+	f.Scale = LogScale
+	log := f.position(200)
+	f.Scale = MelScale
+	assert.Less(t, f.position(200), log)
+	f.Scale = BarkScale
+	assert.Less(t, f.position(200), log)
+}
@@ -0,0 +1,65 @@
+package audio
+
+import (
+	"github.com/lucasb-eyer/go-colorful"
+
+	"currents/internal/dsp"
+)
+
+const (
+	// How many buffers the harmonic median is taken over, around
+	// 400ms with the default frame size
+	separationFrames = 17
+	// How many bins the percussive median is taken over
+	separationBins = 17
+)
+
+// Separation describes how the spectrum is split into its harmonic and
+// percussive parts, so the drums can pulse the brightness while the
+// harmonic content decides the hue
+type Separation struct {
+	// Whether the spectrum is separated. The frequency, pitch class and
+	// descriptors are then found from the harmonic part and the beats
+	// from the percussive part
+	Enabled bool
+	// How much the colour dims between percussive hits, from 0 to 1
+	Pulse float64
+}
+
+// DefaultSeparation returns a disabled separation which
+// dims the colour a little between hits when enabled
+func DefaultSeparation() Separation {
+	return Separation{Pulse: 0.6}
+}
+
+// separate splits the spectrum of the buffer into its harmonic and
+// percussive parts if the separation is enabled
+func (f *FFT) separate(a *analysis) {
+	if !f.Separation.Enabled {
+		a.hpss = nil
+		return
+	}
+
+	if a.hpss == nil {
+		a.hpss = dsp.NewHPSS(separationFrames, separationBins)
+	}
+	a.harmonic, a.percussive = a.hpss.Separate(a.harmonic[:0], a.percussive[:0], a.magnitudes)
+	a.tonal, a.transient = a.harmonic, a.percussive
+}
+
+// pulse dims the colour c between the hits of the percussive part
+func (f *FFT) pulse(a *analysis, c colorful.Color) colorful.Color {
+	if !f.Separation.Enabled {
+		return c
+	}
+
+	var sum float64
+	for _, m := range a.percussive {
+		sum += m * m
+	}
+	power := 2 * sum / (float64(a.fftSize) * a.windowPower)
+	level := a.percussiveAGC.normalise(decibels(power), a.hopDuration, f.AGC)
+
+	h, s, v := c.Hsv()
+	return colorful.Hsv(h, s, v*(1-f.Separation.Pulse*(1-level)))
+}
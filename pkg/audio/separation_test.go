@@ -0,0 +1,64 @@
+package audio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lucasb-eyer/go-colorful"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeparation(t *testing.T) {
+	f, err := NewFFT(&Config{Channels: 1, SampleRate: 44100, Format: F32})
+	assert.Nil(t, err)
+	f.Separation.Enabled = true
+
+	// A steady note with drums over the top
+	bin := 44100.0 / fftFrames
+	samples := sine(10*bin, 1, 44100, 2*time.Second)
+	for i, c := range clicks(44100, 500*time.Millisecond, 2*time.Second) {
+		samples[i] += c
+	}
+	f.Write(encodeF32(samples))
+
+	var a analysis
+	var frames int
+	red := colorful.Color{R: 1}
+	var hit, rest float64
+	for f.fill(&a) {
+		f.spectrum(&a)
+		f.separate(&a)
+		f.onset(&a)
+		frames++
+		_, _, v := f.pulse(&a, red).Hsv()
+
+		// The parts add back up to the spectrum
+		for k, m := range a.magnitudes {
+			assert.InDelta(t, m, a.harmonic[k]+a.percussive[k], 1e-9)
+		}
+		if frames < separationFrames {
+			continue
+		}
+
+		// The note is harmonic and the drums are percussive
+		assert.Greater(t, a.tonal[10], 0.9*a.magnitudes[10])
+		high := len(a.magnitudes) / 2
+		if a.magnitudes[high]*2/a.windowSum > 0.01 {
+			assert.Greater(t, a.transient[high], 0.9*a.magnitudes[high])
+			hit = v
+		} else {
+			rest = v
+		}
+	}
+
+	// The colour is bright on the hits and dims between them
+	assert.Greater(t, hit, 0.9)
+	assert.Less(t, rest, 0.6)
+	assert.Greater(t, rest, 0.0)
+
+	// Without separation the whole spectrum is used for everything
+	f.Separation.Enabled = false
+	f.separate(&a)
+	assert.Nil(t, a.hpss)
+	assert.Equal(t, red, f.pulse(&a, red))
+}
@@ -0,0 +1,240 @@
+package audio
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+var (
+	ErrInvalidSmoothingFilter = errors.New("smoothing filter specified is invalid")
+	ErrInvalidSmoothingTarget = errors.New("smoothing target specified is invalid")
+)
+
+// Cutoff in Hz of the one-euro filter's estimate of how fast the value moves
+const oneEuroDerivativeCutoff = 1
+
+// Smoother filters a stream of values which arrive dt apart
+type Smoother interface {
+	// Smooth returns the filtered value after x arrives
+	Smooth(x float64, dt time.Duration) float64
+}
+
+// SmoothingFilter is the filter used to smooth the colour changes
+type SmoothingFilter int
+
+const (
+	// NoSmoothing leaves the values alone
+	NoSmoothing SmoothingFilter = iota
+	// MovingAverage is an exponential moving average
+	MovingAverage
+	// Spring is a critically damped spring pulled towards the value, it
+	// follows changes smoothly without overshooting
+	Spring
+	// OneEuro is the one-euro filter, it smooths heavily while the value is
+	// steady and lets fast changes through with little lag
+	OneEuro
+	// SlewLimiter limits how quickly the value is allowed to change
+	SlewLimiter
+)
+
+// SmoothingFilters lists every filter in the order they're declared
+var SmoothingFilters = []SmoothingFilter{NoSmoothing, MovingAverage, Spring, OneEuro, SlewLimiter}
+
+func (sf SmoothingFilter) String() string {
+	return [...]string{"None", "EMA", "Spring", "One euro", "Slew"}[sf]
+}
+
+// SmoothingTarget is what the smoothing filter is applied to
+type SmoothingTarget int
+
+const (
+	// SmoothFrequency smooths the frequency's position on the scale, so it
+	// only affects the FrequencyRamp mapping
+	SmoothFrequency SmoothingTarget = iota
+	// SmoothColour smooths the colour in the OkLab space, so it's
+	// perceptually even and affects every mapping
+	SmoothColour
+)
+
+// SmoothingTargets lists every target in the order they're declared
+var SmoothingTargets = []SmoothingTarget{SmoothFrequency, SmoothColour}
+
+func (st SmoothingTarget) String() string {
+	return [...]string{"Frequency", "Colour"}[st]
+}
+
+// Smoothing describes the filter used to smooth the colour changes,
+// positions and OkLab coordinates both roughly range from 0 to 1 so the
+// settings behave the same for either target
+type Smoothing struct {
+	// Which filter is used
+	Filter SmoothingFilter
+	// What the filter is applied to
+	Target SmoothingTarget
+	// Time constant of the moving average and the spring
+	Time time.Duration
+	// Cutoff in Hz of the one-euro filter while the value is steady
+	MinCutoff float64
+	// How much the one-euro filter's cutoff rises with the speed of the value
+	Beta float64
+	// Largest change per second the slew limiter allows
+	Rate float64
+}
+
+func DefaultSmoothing() Smoothing {
+	return Smoothing{
+		Filter:    OneEuro,
+		Target:    SmoothFrequency,
+		Time:      150 * time.Millisecond,
+		MinCutoff: 1,
+		Beta:      5,
+		Rate:      2,
+	}
+}
+
+// New creates a Smoother with the settings, nil is returned for NoSmoothing
+func (s Smoothing) New() Smoother {
+	switch s.Filter {
+	case NoSmoothing:
+		return nil
+	case MovingAverage:
+		return &movingAverage{tau: s.Time}
+	case Spring:
+		return &spring{tau: s.Time}
+	case OneEuro:
+		return &oneEuro{minCutoff: s.MinCutoff, beta: s.Beta}
+	case SlewLimiter:
+		return &slewLimiter{rate: s.Rate}
+	}
+	panic(ErrInvalidSmoothingFilter)
+}
+
+type movingAverage struct {
+	tau    time.Duration
+	y      float64
+	primed bool
+}
+
+func (m *movingAverage) Smooth(x float64, dt time.Duration) float64 {
+	if !m.primed {
+		m.y, m.primed = x, true
+	}
+	m.y += smoothing(dt, m.tau) * (x - m.y)
+	return m.y
+}
+
+type spring struct {
+	tau      time.Duration
+	y        float64
+	velocity float64
+	primed   bool
+}
+
+// Smooth integrates the spring with the approximation from Game Programming
+// Gems 4, "Critically Damped Ease-In/Ease-Out Smoothing", which is stable
+// for any step
+func (s *spring) Smooth(x float64, dt time.Duration) float64 {
+	if !s.primed || s.tau <= 0 {
+		s.y, s.velocity, s.primed = x, 0, true
+		return s.y
+	}
+
+	omega := 2 / s.tau.Seconds()
+	step := omega * dt.Seconds()
+	decay := 1 / (1 + step + 0.48*step*step + 0.235*step*step*step)
+
+	change := s.y - x
+	temp := (s.velocity + omega*change) * dt.Seconds()
+	s.velocity = (s.velocity - omega*temp) * decay
+	s.y = x + (change+temp)*decay
+	return s.y
+}
+
+type oneEuro struct {
+	minCutoff  float64
+	beta       float64
+	y          float64
+	derivative float64
+	primed     bool
+}
+
+// Smooth filters the value as described by Casiez et al. in
+// "1€ Filter: A Simple Speed-based Low-pass Filter for Noisy Input in
+// Interactive Systems"
+func (o *oneEuro) Smooth(x float64, dt time.Duration) float64 {
+	if !o.primed || dt <= 0 {
+		o.y, o.derivative, o.primed = x, 0, true
+		return o.y
+	}
+
+	// alpha is the weight of a low-pass filter with the cutoff in Hz
+	alpha := func(cutoff float64) float64 {
+		tau := 1 / (2 * math.Pi * cutoff)
+		return 1 / (1 + tau/dt.Seconds())
+	}
+
+	speed := (x - o.y) / dt.Seconds()
+	o.derivative += alpha(oneEuroDerivativeCutoff) * (speed - o.derivative)
+	cutoff := o.minCutoff + o.beta*math.Abs(o.derivative)
+	o.y += alpha(cutoff) * (x - o.y)
+	return o.y
+}
+
+type slewLimiter struct {
+	rate   float64
+	y      float64
+	primed bool
+}
+
+func (s *slewLimiter) Smooth(x float64, dt time.Duration) float64 {
+	if !s.primed {
+		s.y, s.primed = x, true
+		return s.y
+	}
+
+	limit := s.rate * dt.Seconds()
+	s.y += math.Max(-limit, math.Min(limit, x-s.y))
+	return s.y
+}
+
+// smoothers returns the filters for the current settings, they are
+// recreated whenever the settings change
+func (f *FFT) smoothers(a *analysis) []Smoother {
+	if f.Smoothing.Target != SmoothFrequency && f.Smoothing.Target != SmoothColour {
+		panic(ErrInvalidSmoothingTarget)
+	}
+	if a.smoothing != f.Smoothing || a.smoothers == nil {
+		a.smoothing = f.Smoothing
+		a.smoothers = make([]Smoother, 3)
+		for i := range a.smoothers {
+			a.smoothers[i] = f.Smoothing.New()
+		}
+	}
+	return a.smoothers
+}
+
+// smoothPosition filters the position of the frequency on the scale
+func (f *FFT) smoothPosition(a *analysis, pos float64) float64 {
+	if f.Smoothing.Filter == NoSmoothing || f.Smoothing.Target != SmoothFrequency {
+		return pos
+	}
+	return f.smoothers(a)[0].Smooth(pos, a.hopDuration)
+}
+
+// smoothColour filters the colour in the OkLab space
+func (f *FFT) smoothColour(a *analysis, c colorful.Color) colorful.Color {
+	if f.Smoothing.Filter == NoSmoothing || f.Smoothing.Target != SmoothColour {
+		return c
+	}
+
+	s := f.smoothers(a)
+	l, x, y := okLab(c)
+	return fromOkLab(
+		s[0].Smooth(l, a.hopDuration),
+		s[1].Smooth(x, a.hopDuration),
+		s[2].Smooth(y, a.hopDuration),
+	)
+}
@@ -0,0 +1,98 @@
+package audio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lucasb-eyer/go-colorful"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSmoothingFilters(t *testing.T) {
+	dt := 10 * time.Millisecond
+	for _, sf := range SmoothingFilters[1:] {
+		s := DefaultSmoothing()
+		s.Filter = sf
+		smoother := s.New()
+
+		// Each filter starts at the first value and follows a step without
+		// overshooting it
+		assert.Equal(t, 0.0, smoother.Smooth(0, dt), sf.String())
+		last := 0.0
+		for i := 0; i < 200; i++ {
+			y := smoother.Smooth(1, dt)
+			assert.GreaterOrEqual(t, y, last, sf.String())
+			assert.LessOrEqual(t, y, 1.0, sf.String())
+			last = y
+		}
+		assert.InDelta(t, 1, last, 1e-2, sf.String())
+	}
+	assert.Nil(t, Smoothing{Filter: NoSmoothing}.New())
+
+	// The slew limiter moves at a constant rate
+	slew := Smoothing{Filter: SlewLimiter, Rate: 2}.New()
+	slew.Smooth(0, dt)
+	for i := 0; i < 9; i++ {
+		slew.Smooth(1, dt)
+	}
+	assert.InDelta(t, 0.2, slew.Smooth(1, dt), 1e-9)
+
+	// The one-euro filter removes jitter but keeps up with fast movements
+	// better than a moving average which removes as much jitter
+	euro := Smoothing{Filter: OneEuro, MinCutoff: 1, Beta: 5}.New()
+	ema := Smoothing{Filter: MovingAverage, Time: 160 * time.Millisecond}.New()
+	var euroJitter, emaJitter float64
+	for i := 0; i < 100; i++ {
+		x := 0.5 + 0.01*float64(i%2*2-1)
+		euroJitter = euro.Smooth(x, dt) - 0.5
+		emaJitter = ema.Smooth(x, dt) - 0.5
+	}
+	assert.Less(t, euroJitter*euroJitter, 1e-5)
+	assert.Less(t, emaJitter*emaJitter, 1e-5)
+
+	var euroLag, emaLag float64
+	for i := 1; i <= 20; i++ {
+		x := 0.5 + 0.02*float64(i)
+		euroLag = x - euro.Smooth(x, dt)
+		emaLag = x - ema.Smooth(x, dt)
+	}
+	assert.Less(t, euroLag, emaLag)
+}
+
+func TestSmoothingColour(t *testing.T) {
+	// OkLab conversions round trip and white has a lightness of 1
+	for _, c := range []colorful.Color{{R: 1}, {G: 1}, {B: 1}, {R: 0.2, G: 0.5, B: 0.9}, {}} {
+		assert.True(t, fromOkLab(okLab(c)).AlmostEqualRgb(c), c.Hex())
+	}
+	l, _, _ := okLab(colorful.Color{R: 1, G: 1, B: 1})
+	assert.InDelta(t, 1, l, 1e-4)
+
+	f, err := NewFFT(DefaultConfig())
+	assert.Nil(t, err)
+	a := analysis{hopDuration: 10 * time.Millisecond}
+	colour := func(freq float64) colorful.Color {
+		a.frequency = freq
+		return f.colour(&a)
+	}
+
+	// The frequency is smoothed by default
+	assert.Equal(t, OneEuro, f.Smoothing.Filter)
+	assert.Equal(t, SmoothFrequency, f.Smoothing.Target)
+	colour(100)
+	smoothed := colour(2000)
+
+	f.Smoothing.Filter = NoSmoothing
+	low, high := colour(100), colour(2000)
+	assert.False(t, smoothed.AlmostEqualRgb(high))
+
+	// Without smoothing the colour jumps to the new frequency, with it the
+	// colour only moves part of the way there
+	for _, target := range SmoothingTargets {
+		f.Smoothing = Smoothing{Filter: SlewLimiter, Target: target, Rate: 1}
+		assert.True(t, colour(100).AlmostEqualRgb(low), target.String())
+
+		c := colour(2000)
+		assert.False(t, c.AlmostEqualRgb(low), target.String())
+		assert.False(t, c.AlmostEqualRgb(high), target.String())
+	}
+}
@@ -0,0 +1,122 @@
+package audio
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// Source produces interleaved audio frames and writes them to a writer,
+// usually an FFT. Capture devices, files, generators and network streams
+// can all implement Source so they can drive the visualisation
+type Source interface {
+	// Format returns the layout of the frames the source writes
+	Format() Config
+	// Start begins writing frames to w without blocking. If the source
+	// stops on its own, i.e. it is exhausted or writing to w fails, then
+	// done is called with the reason
+	Start(w io.Writer, done func(error)) error
+	// Stop halts the source, stopping a source which isn't running is a no-op
+	Stop() error
+	// Frames returns how many frames have been written since the source started
+	Frames() uint64
+}
+
+// FormatWriter is a writer which needs to know the layout of the frames
+// written to it. Audio.Start sets the format before it starts each source
+type FormatWriter interface {
+	io.Writer
+	SetFormat(conf Config) error
+}
+
+// StopWriter is a writer which needs to know when frames stop being
+// written to it. Audio.Run tells it once a source has been stopped
+// deliberately, rather than running out or failing
+type StopWriter interface {
+	io.Writer
+	Stopped()
+}
+
+// streamer runs the goroutine of a source which streams its frames from
+// a reader and ensures nothing is written once the source is stopped
+type streamer struct {
+	frames uint64 // Frames written, must be accessed atomically and kept first for alignment
+
+	m       sync.Mutex
+	stop    chan struct{} // Closed to tell the streaming goroutine to exit, nil if not running
+	stopped chan struct{} // Closed once the streaming goroutine has exited
+}
+
+func (s *streamer) Frames() uint64 {
+	return atomic.LoadUint64(&s.frames)
+}
+
+func (s *streamer) Stop() error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.halt()
+	return nil
+}
+
+// start stops the previous goroutine, if any, and then runs fn in a new one.
+// It must be called while holding s.m
+func (s *streamer) start(fn func(stop chan struct{})) {
+	s.halt()
+	s.stop, s.stopped = make(chan struct{}), make(chan struct{})
+	atomic.StoreUint64(&s.frames, 0)
+
+	go func(stop, stopped chan struct{}) {
+		defer close(stopped)
+		fn(stop)
+	}(s.stop, s.stopped)
+}
+
+func (s *streamer) halt() {
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+// halted stops the goroutine, if any, and waits for it to exit so
+// nothing else is using the reader. It must be called while holding
+// s.m, which is released while waiting since the goroutine may be
+// waiting for it to write
+func (s *streamer) halted() {
+	for s.stop != nil {
+		stopped := s.stopped
+		s.halt()
+		s.m.Unlock()
+		<-stopped
+		s.m.Lock()
+	}
+}
+
+// write writes p, which contains the given number of frames, to w.
+// It returns false if the source has been stopped
+func (s *streamer) write(w io.Writer, p []byte, frames int, stop chan struct{}) (bool, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	select {
+	case <-stop:
+		return false, nil
+	default:
+	}
+
+	if _, err := w.Write(p); err != nil {
+		return true, err
+	}
+	atomic.AddUint64(&s.frames, uint64(frames))
+	return true, nil
+}
+
+// finish reports why a source ended unless it was stopped deliberately
+func finish(done func(error), stop chan struct{}, err error) {
+	select {
+	case <-stop:
+	default:
+		done(err)
+	}
+}
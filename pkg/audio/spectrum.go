@@ -0,0 +1,92 @@
+package audio
+
+import (
+	"time"
+)
+
+// Spectrum is a snapshot of the magnitude spectrum of one buffer, the
+// slices are shared between subscribers so they must not be modified
+type Spectrum struct {
+	// When the buffer was analysed
+	Time time.Time
+	// Frequency in Hz of each bin
+	Frequencies []float64
+	// Magnitude of each bin, scaled so a full scale sine wave
+	// has a magnitude of 1
+	Magnitudes []float64
+	// Harmonic and percussive parts of the magnitudes, scaled in the same
+	// way. They're nil unless the FFT's Separation is enabled
+	Harmonic   []float64
+	Percussive []float64
+	// Descriptors of the shape of the spectrum
+	Descriptors SpectralDescriptors
+}
+
+// Subscribe returns a chan which receives the spectrum of every buffer
+// analysed and a func which unsubscribes and closes it. Spectrums are
+// dropped if the chan's buffer is full so slow subscribers never stall
+// the analysis
+func (f *FFT) Subscribe(buffer int) (<-chan Spectrum, func()) {
+	ch := make(chan Spectrum, buffer)
+
+	f.subsM.Lock()
+	defer f.subsM.Unlock()
+	if f.subs == nil {
+		f.subs = make(map[chan Spectrum]struct{})
+	}
+	f.subs[ch] = struct{}{}
+
+	return ch, func() {
+		f.subsM.Lock()
+		defer f.subsM.Unlock()
+		if _, ok := f.subs[ch]; ok {
+			delete(f.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// publish sends the spectrum of the buffer to the subscribers
+func (f *FFT) publish(a *analysis, at time.Time) {
+	f.subsM.Lock()
+	defer f.subsM.Unlock()
+	if len(f.subs) == 0 {
+		return
+	}
+
+	// The frequencies only change with the size of the FFT so they're
+	// reused until then, a new slice is made as subscribers may hold the old
+	if len(a.frequencies) != len(a.magnitudes) || a.frequencyBin != a.binSize {
+		a.frequencies = make([]float64, len(a.magnitudes))
+		for i := range a.frequencies {
+			a.frequencies[i] = float64(i) * a.binSize
+		}
+		a.frequencyBin = a.binSize
+	}
+
+	s := Spectrum{
+		Time:        at,
+		Frequencies: a.frequencies,
+		Magnitudes:  make([]float64, len(a.magnitudes)),
+		Descriptors: a.descriptors,
+	}
+	scale := 2 / a.windowSum
+	for i, m := range a.magnitudes {
+		s.Magnitudes[i] = m * scale
+	}
+	if a.hpss != nil {
+		s.Harmonic = make([]float64, len(a.harmonic))
+		s.Percussive = make([]float64, len(a.percussive))
+		for i := range a.harmonic {
+			s.Harmonic[i] = a.harmonic[i] * scale
+			s.Percussive[i] = a.percussive[i] * scale
+		}
+	}
+
+	for ch := range f.subs {
+		select {
+		case ch <- s:
+		default:
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package audio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpectrumSubscribe(t *testing.T) {
+	f, err := NewFFT(&Config{Channels: 1, SampleRate: 44100, Format: F32})
+	assert.Nil(t, err)
+	first, unsubscribe := f.Subscribe(1)
+	second, _ := f.Subscribe(1)
+	defer runFFT(t, f)()
+
+	// A sine wave in the middle of a bin has a magnitude of its amplitude
+	freq := 10 * 44100.0 / fftFrames
+	f.Write(encodeF32(sine(freq, 1, 44100, 50*time.Millisecond)))
+	for _, ch := range []<-chan Spectrum{first, second} {
+		select {
+		case s := <-ch:
+			assert.Len(t, s.Magnitudes, fftFrames/2)
+			assert.Len(t, s.Frequencies, fftFrames/2)
+			assert.WithinDuration(t, time.Now(), s.Time, time.Second)
+
+			peak := peakBin(s.Magnitudes)
+			assert.InDelta(t, freq, s.Frequencies[peak], 1e-9)
+			assert.InDelta(t, 0.5, s.Magnitudes[peak], 1e-3)
+		case <-time.After(time.Second):
+			t.Fatal("spectrum was not published")
+		}
+	}
+
+	// Unsubscribing closes the chan and can be done more than once
+	unsubscribe()
+	unsubscribe()
+	for range first {
+	}
+}
@@ -0,0 +1,79 @@
+package audio
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Stats describes how much work FFT's analysis loop is doing
+type Stats struct {
+	// How many buffers were processed
+	Buffers uint64
+	// How many of the processed buffers had their FFT calculated
+	Analyses uint64
+	// Average and longest time taken to process a buffer
+	FrameTime    time.Duration
+	MaxFrameTime time.Duration
+	// Fraction of the time the loop spent processing instead of sleeping
+	Load float64
+	// How many samples have been discarded because the loop fell behind
+	Dropped uint64
+}
+
+func (st Stats) String() string {
+	return fmt.Sprintf("Load: %.2f%%, Frame Time: %v (max %v), Analyses: %d/%d, Dropped: %d",
+		st.Load*100, st.FrameTime, st.MaxFrameTime, st.Analyses, st.Buffers, st.Dropped)
+}
+
+// stats accumulates the figures for Stats over a period of time
+type stats struct {
+	m sync.Mutex
+
+	since    time.Time     // When the period began
+	busy     time.Duration // Total time spent processing
+	max      time.Duration
+	buffers  uint64
+	analyses uint64
+}
+
+// record adds the time taken to process a buffer to the current period
+func (s *stats) record(d time.Duration, analysed bool) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.busy += d
+	s.buffers++
+	if analysed {
+		s.analyses++
+	}
+	if d > s.max {
+		s.max = d
+	}
+}
+
+// reset returns the figures for the current period and begins a new one
+func (s *stats) reset() Stats {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	now := time.Now()
+	st := Stats{
+		Buffers:      s.buffers,
+		Analyses:     s.analyses,
+		MaxFrameTime: s.max,
+	}
+	if s.buffers > 0 {
+		st.FrameTime = s.busy / time.Duration(s.buffers)
+	}
+	if elapsed := now.Sub(s.since); elapsed > 0 {
+		st.Load = float64(s.busy) / float64(elapsed)
+	}
+
+	s.since = now
+	s.busy = 0
+	s.max = 0
+	s.buffers = 0
+	s.analyses = 0
+	return st
+}
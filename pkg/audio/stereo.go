@@ -0,0 +1,116 @@
+package audio
+
+import (
+	"errors"
+	"time"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+var ErrInvalidChannelMode = errors.New("channel mode specified is invalid")
+
+// ChannelMode is how the channels of the audio are visualised
+type ChannelMode int
+
+const (
+	// Mono mixes every channel together and produces a single colour
+	Mono ChannelMode = iota
+	// Stereo also analyses the left and right channels separately and
+	// produces a colour for each of them on StereoHues
+	Stereo
+	// MidSide also analyses the sum and difference of the left and right
+	// channels, which brings out what's panned wide in the mix
+	MidSide
+)
+
+// ChannelModes lists every channel mode in the order they're declared
+var ChannelModes = []ChannelMode{Mono, Stereo, MidSide}
+
+func (cm ChannelMode) String() string {
+	return [...]string{"Mono", "Stereo", "Mid/Side"}[cm]
+}
+
+// mixes returns how each side is mixed from the channels
+func (cm ChannelMode) mixes() [2]mix {
+	switch cm {
+	case Stereo:
+		return [2]mix{mixLeft, mixRight}
+	case MidSide:
+		return [2]mix{mixMid, mixSide}
+	}
+	panic(ErrInvalidChannelMode)
+}
+
+// StereoColour is the colour of each half of the strip, in the MidSide
+// mode Left is the mid colour and Right is the side colour
+type StereoColour struct {
+	Left  colorful.Color
+	Right colorful.Color
+}
+
+// mix is how a frame is mixed down into a single sample
+type mix int
+
+const (
+	mixDown  mix = iota // Average of every channel
+	mixLeft             // First channel
+	mixRight            // Second channel
+	mixMid              // Average of the first two channels
+	mixSide             // Half the difference of the first two channels
+)
+
+// downmix appends each frame of buf mixed down into a single sample to dst,
+// mono audio is used for both the left and right channels
+func downmix(dst, buf []float32, channels int, m mix) []float32 {
+	for i := 0; i+channels <= len(buf); i += channels {
+		frame := buf[i : i+channels]
+		left, right := frame[0], frame[0]
+		if channels > 1 {
+			right = frame[1]
+		}
+
+		var s float32
+		switch m {
+		case mixDown:
+			for _, x := range frame {
+				s += x
+			}
+			s /= float32(channels)
+		case mixLeft:
+			s = left
+		case mixRight:
+			s = right
+		case mixMid:
+			s = (left + right) / 2
+		case mixSide:
+			s = (left - right) / 2
+		}
+		dst = append(dst, s)
+	}
+	return dst
+}
+
+// stereo analyses each side of the buffer a holds and returns their colours,
+// the beats are followed from a so both sides react to them together
+func (f *FFT) stereo(a *analysis, sides *[2]analysis, analysed bool, began time.Time) StereoColour {
+	var colours [2]colorful.Color
+	mixes := f.ChannelMode.mixes()
+	for i := range sides {
+		s := &sides[i]
+		s.mix = mixes[i]
+		s.buf, s.hop = a.buf, a.hop
+		f.spectrum(s)
+		f.separate(s)
+		f.onset(s)
+		f.describe(s)
+
+		s.beats, s.lastBeat, s.phase, s.cycled = a.beats, a.lastBeat, a.phase, a.cycled
+		if analysed || s.update.IsZero() {
+			f.analyse(s)
+		}
+		f.loudness(s)
+		f.energies(s)
+		colours[i] = f.shade(s, began)
+	}
+	return StereoColour{Left: colours[0], Right: colours[1]}
+}
@@ -0,0 +1,104 @@
+package audio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lucasb-eyer/go-colorful"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownmix(t *testing.T) {
+	buf := []float32{1, 0.5, 3, -1}
+	assert.Equal(t, []float32{0.75, 1}, downmix(nil, buf, 2, mixDown))
+	assert.Equal(t, []float32{1, 3}, downmix(nil, buf, 2, mixLeft))
+	assert.Equal(t, []float32{0.5, -1}, downmix(nil, buf, 2, mixRight))
+	assert.Equal(t, []float32{0.75, 1}, downmix(nil, buf, 2, mixMid))
+	assert.Equal(t, []float32{0.25, 2}, downmix(nil, buf, 2, mixSide))
+
+	// Mono audio is on both sides
+	assert.Equal(t, buf, downmix(nil, buf, 1, mixRight))
+	assert.Equal(t, []float32{0, 0, 0, 0}, downmix(nil, buf, 1, mixSide))
+}
+
+func TestStereo(t *testing.T) {
+	f, err := NewFFT(&Config{Channels: 2, SampleRate: 44100, Format: F32})
+	assert.Nil(t, err)
+
+	// Each channel has its own note
+	left, right := sine(440, 1, 44100, 100*time.Millisecond), sine(1500, 1, 44100, 100*time.Millisecond)
+	samples := make([]float64, 0, 2*len(left))
+	for i := range left {
+		samples = append(samples, left[i], right[i])
+	}
+
+	stereo := func(mode ChannelMode, samples []float64) (c StereoColour) {
+		f.ChannelMode = mode
+		f.Write(encodeF32(samples))
+		var a analysis
+		var sides [2]analysis
+		for f.fill(&a) {
+			f.spectrum(&a)
+			f.onset(&a)
+			c = f.stereo(&a, &sides, true, time.Now())
+		}
+		return c
+	}
+
+	assertHues := func(c StereoColour, leftFreq, rightFreq float64) {
+		leftHue, _, _ := c.Left.Hsv()
+		rightHue, _, _ := c.Right.Hsv()
+		assert.InDelta(t, f.position(leftFreq)*f.TotalHues, leftHue, 10)
+		assert.InDelta(t, f.position(rightFreq)*f.TotalHues, rightHue, 10)
+	}
+	assertHues(stereo(Stereo, samples), 440, 1500)
+
+	// A note in phase on both channels is in the mid and
+	// one out of phase is in the side
+	for i := range left {
+		samples[2*i], samples[2*i+1] = left[i]+right[i], left[i]-right[i]
+	}
+	assertHues(stereo(MidSide, samples), 440, 1500)
+}
+
+func TestStereoOverTime(t *testing.T) {
+	f, err := NewFFT(&Config{Channels: 2, SampleRate: 44100, Format: F32})
+	assert.Nil(t, err)
+	f.ChannelMode = Stereo
+	f.Smoothing = Smoothing{Filter: SlewLimiter, Target: SmoothFrequency, Rate: 1}
+	f.Gate.Hold, f.Gate.Fade = 20*time.Millisecond, 50*time.Millisecond
+
+	var a analysis
+	var sides [2]analysis
+	stereo := func(leftFreq, rightFreq float64) (c StereoColour) {
+		left, right := sine(leftFreq, 1, 44100, 100*time.Millisecond), sine(rightFreq, 1, 44100, 100*time.Millisecond)
+		samples := make([]float64, 0, 2*len(left))
+		for i := range left {
+			samples = append(samples, left[i], right[i])
+		}
+		f.Write(encodeF32(samples))
+		for f.fill(&a) {
+			f.spectrum(&a)
+			f.onset(&a)
+			c = f.stereo(&a, &sides, true, time.Now())
+		}
+		return c
+	}
+
+	// The position of each side moves towards its new note at the slew rate
+	before := stereo(440, 1500)
+	after := stereo(1500, 440)
+	for _, c := range [][3]colorful.Color{
+		{before.Left, after.Left, colorful.Hsv(f.position(1500)*f.TotalHues, 1, 1)},
+		{before.Right, after.Right, colorful.Hsv(f.position(440)*f.TotalHues, 1, 1)},
+	} {
+		assert.False(t, c[0].AlmostEqualRgb(c[1]))
+		assert.False(t, c[1].AlmostEqualRgb(c[2]))
+	}
+
+	// And both sides fade once the audio is quieter than the threshold
+	f.Gate.Threshold = 0
+	silent := stereo(440, 1500)
+	assert.True(t, silent.Left.AlmostEqualRgb(colorful.Color{}))
+	assert.True(t, silent.Right.AlmostEqualRgb(colorful.Color{}))
+}
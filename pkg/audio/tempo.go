@@ -0,0 +1,115 @@
+package audio
+
+import (
+	"fmt"
+	"time"
+
+	"currents/internal/dsp"
+)
+
+const (
+	// How much of the onset envelope the tempo is estimated from
+	tempoWindow = 6 * time.Second
+	// The tempo isn't estimated until there's this much of the envelope
+	minTempoWindow = 2 * time.Second
+	// Range of tempos which can be detected
+	minBPM = 60
+	maxBPM = 180
+	// TempoSync only follows tempos with at least this confidence
+	minTempoConfidence = 0.3
+)
+
+// Tempo is an estimate of the tempo of the audio
+type Tempo struct {
+	// Beats per minute, zero if the tempo is unknown
+	BPM float64
+	// How periodic the onsets are at this tempo, from 0 to 1
+	Confidence float64
+}
+
+// Period returns the duration of one beat, zero if the tempo is unknown
+func (t Tempo) Period() time.Duration {
+	if t.BPM <= 0 {
+		return 0
+	}
+	return time.Duration(float64(time.Minute) / t.BPM)
+}
+
+func (t Tempo) String() string {
+	if t.BPM == 0 {
+		return "Unknown tempo"
+	}
+	return fmt.Sprintf("%.1f BPM (%.0f%% confidence)", t.BPM, t.Confidence*100)
+}
+
+// Tempo returns the most recent estimate of the tempo
+func (f *FFT) Tempo() Tempo {
+	t, _ := f.tempo.Load().(Tempo)
+	return t
+}
+
+// envelope adds the spectral flux of the buffer to the onset envelope,
+// the envelope is cleared if the duration of each buffer changes
+func (f *FFT) envelope(a *analysis) {
+	if a.envelopeHop != a.hopDuration {
+		a.envelope = a.envelope[:0]
+		a.envelopeHop = a.hopDuration
+	}
+
+	if size := int(tempoWindow / a.hopDuration); len(a.envelope) >= size {
+		copy(a.envelope, a.envelope[len(a.envelope)-size+1:])
+		a.envelope = a.envelope[:size-1]
+	}
+	a.envelope = append(a.envelope, a.flux)
+}
+
+// tempo finds the tempo whose period the onset envelope correlates with best
+func (a *analysis) tempo() Tempo {
+	hop := a.hopDuration
+	if hop <= 0 || len(a.envelope) < int(minTempoWindow/hop) {
+		return Tempo{}
+	}
+
+	// Only the variation of the envelope matters
+	var mean float64
+	for _, x := range a.envelope {
+		mean += x
+	}
+	mean /= float64(len(a.envelope))
+	a.centred = a.centred[:0]
+	for _, x := range a.envelope {
+		a.centred = append(a.centred, x-mean)
+	}
+
+	minLag := int(time.Minute / maxBPM / hop)
+	maxLag := int(time.Minute / minBPM / hop)
+	a.correlation = dsp.Autocorrelate(a.correlation[:0], a.centred, maxLag+1)
+	if minLag < 1 || len(a.correlation) < maxLag+2 || a.correlation[0] <= 0 {
+		return Tempo{}
+	}
+
+	best := minLag
+	for lag := minLag; lag <= maxLag; lag++ {
+		if a.correlation[lag] > a.correlation[best] {
+			best = lag
+		}
+	}
+
+	// Interpolate between lags with a parabola through the peak
+	lag := float64(best)
+	if l, c, r := a.correlation[best-1], a.correlation[best], a.correlation[best+1]; l-2*c+r != 0 {
+		lag += 0.5 * (l - r) / (l - 2*c + r)
+	}
+
+	confidence := a.correlation[best] / a.correlation[0]
+	if confidence < 0 {
+		confidence = 0
+	} else if confidence > 1 {
+		confidence = 1
+	}
+
+	return Tempo{
+		BPM:        float64(time.Minute) / (lag * float64(hop)),
+		Confidence: confidence,
+	}
+}
@@ -0,0 +1,74 @@
+package audio
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// envelopeTempo analyses the samples a second at a time, so they
+// don't overflow the ring buffer, and returns the estimated tempo
+func envelopeTempo(t *testing.T, samples []float64) Tempo {
+	f, err := NewFFT(&Config{Channels: 1, SampleRate: 44100, Format: F32})
+	assert.Nil(t, err)
+	f.HopSize = 256
+
+	var a analysis
+	for len(samples) > 0 {
+		n := 44100
+		if n > len(samples) {
+			n = len(samples)
+		}
+		f.Write(encodeF32(samples[:n]))
+		samples = samples[n:]
+
+		for f.fill(&a) {
+			f.spectrum(&a)
+			f.onset(&a)
+			f.envelope(&a)
+		}
+	}
+	return a.tempo()
+}
+
+func TestTempo(t *testing.T) {
+	for _, bpm := range []float64{90, 120, 150} {
+		interval := time.Duration(float64(time.Minute) / bpm)
+		tempo := envelopeTempo(t, clicks(44100, interval, 6*time.Second))
+		assert.InDelta(t, bpm, tempo.BPM, 2)
+		assert.Greater(t, tempo.Confidence, 0.5)
+		assert.InDelta(t, float64(interval), float64(tempo.Period()), float64(20*time.Millisecond))
+	}
+
+	// Noise doesn't have a tempo
+	r := rand.New(rand.NewSource(1))
+	noise := make([]float64, 6*44100)
+	for i := range noise {
+		noise[i] = 0.5 * (r.Float64()*2 - 1)
+	}
+	assert.Less(t, envelopeTempo(t, noise).Confidence, 0.3)
+
+	// Too little audio doesn't have a tempo
+	assert.Equal(t, Tempo{}, envelopeTempo(t, clicks(44100, 500*time.Millisecond, time.Second)))
+}
+
+func TestTempoSync(t *testing.T) {
+	f, err := NewFFT(DefaultConfig())
+	assert.Nil(t, err)
+	f.tempo.Store(Tempo{BPM: 120, Confidence: 0.9})
+
+	f.ChangeSampleRate(100 * time.Millisecond)
+	assert.Equal(t, 100*time.Millisecond, f.tickPeriod())
+
+	// Once synced the frequency is calculated every beat
+	f.TempoSync = true
+	f.ChangeSampleRate(100 * time.Millisecond)
+	assert.Equal(t, 500*time.Millisecond, f.tickPeriod())
+
+	// Unless the tempo is uncertain
+	f.tempo.Store(Tempo{BPM: 120, Confidence: 0.1})
+	f.ChangeSampleRate(100 * time.Millisecond)
+	assert.Equal(t, 100*time.Millisecond, f.tickPeriod())
+}
@@ -0,0 +1,240 @@
+package audio
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"time"
+)
+
+var ErrInvalidWAV = errors.New("wav file is invalid")
+var ErrWAVFormat = errors.New("wav sample format is unsupported")
+
+// WAV format tags
+const (
+	wavPCM        = 0x0001
+	wavFloat      = 0x0003
+	wavExtensible = 0xFFFE
+)
+
+// WAVSource is a Source which streams the samples of a PCM or floating
+// point WAV file. Samples are written in the file's own format, the same
+// way a capture device writes them, except for 64-bit floats which are
+// converted to F32
+type WAVSource struct {
+	streamer
+
+	// Whether the frames should be written at the speed they would be
+	// played back at, otherwise they're written as fast as possible
+	RealTime bool
+
+	r         io.Reader
+	conf      Config
+	tag       uint16 // WAV format tag, either wavPCM or wavFloat
+	bits      int    // Bits per sample
+	dataStart int64  // Offset of the sample data, used to rewind seekable files
+	dataSize  int64  // Size of the sample data in bytes, -1 if unknown
+}
+
+// NewWAVSource parses the header of the WAV file in r and returns a source
+// which streams its samples. If r is an io.Seeker then the source can be
+// restarted from the beginning of the file
+func NewWAVSource(r io.Reader) (*WAVSource, error) {
+	ws := &WAVSource{r: r}
+	if err := ws.parseHeader(); err != nil {
+		return nil, err
+	}
+	return ws, nil
+}
+
+func (ws *WAVSource) Format() Config {
+	return ws.conf
+}
+
+// Duration returns the length of the file, or zero if it is unknown
+func (ws *WAVSource) Duration() time.Duration {
+	if ws.dataSize < 0 {
+		return 0
+	}
+	frames := ws.dataSize / int64(ws.frameSize())
+	return time.Duration(frames) * time.Second / time.Duration(ws.conf.SampleRate)
+}
+
+func (ws *WAVSource) Start(w io.Writer, done func(error)) error {
+	ws.m.Lock()
+	defer ws.m.Unlock()
+
+	// Rewind to the start of the data if we've already been streamed,
+	// the old goroutine mustn't be reading while the file is rewound
+	ws.halted()
+	if s, ok := ws.r.(io.Seeker); ok {
+		if _, err := s.Seek(ws.dataStart, io.SeekStart); err != nil {
+			return err
+		}
+	}
+
+	ws.start(func(stop chan struct{}) { ws.stream(w, done, stop) })
+	return nil
+}
+
+func (ws *WAVSource) frameSize() int {
+	return int(ws.conf.Channels) * ws.bits / 8
+}
+
+func (ws *WAVSource) stream(w io.Writer, done func(error), stop chan struct{}) {
+	var r io.Reader = ws.r
+	if ws.dataSize >= 0 {
+		r = io.LimitReader(r, ws.dataSize)
+	}
+
+	frameSize := ws.frameSize()
+	in := make([]byte, frameSize*readerChunkFrames)
+
+	start := time.Now()
+	var written uint64
+	for {
+		// Only whole frames are written, a trailing partial frame is discarded
+		n, err := io.ReadFull(r, in)
+		n -= n % frameSize
+		if n > 0 {
+			out := in[:n]
+			if ws.bits == 64 {
+				out = narrow(out)
+			}
+
+			running, werr := ws.write(w, out, n/frameSize, stop)
+			if !running {
+				return
+			} else if werr != nil {
+				finish(done, stop, werr)
+				return
+			}
+			written += uint64(n / frameSize)
+
+			// Wait until the frames we've written would have finished playing
+			if ws.RealTime {
+				due := start.Add(time.Duration(written) * time.Second / time.Duration(ws.conf.SampleRate))
+				timer := time.NewTimer(time.Until(due))
+				select {
+				case <-stop:
+					timer.Stop()
+					return
+				case <-timer.C:
+				}
+			}
+		}
+
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			finish(done, stop, nil)
+			return
+		} else if err != nil {
+			finish(done, stop, err)
+			return
+		}
+	}
+}
+
+// narrow converts 64-bit float samples into 32-bit float samples in place
+func narrow(b []byte) []byte {
+	n := 0
+	for i := 0; i+8 <= len(b); i += 8 {
+		f := math.Float64frombits(binary.LittleEndian.Uint64(b[i:]))
+		binary.LittleEndian.PutUint32(b[n:], math.Float32bits(float32(f)))
+		n += 4
+	}
+	return b[:n]
+}
+
+// parseHeader reads the RIFF chunks up until the start of the sample data
+func (ws *WAVSource) parseHeader() error {
+	var riff [12]byte
+	if _, err := io.ReadFull(ws.r, riff[:]); err != nil {
+		return ErrInvalidWAV
+	}
+	if string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return ErrInvalidWAV
+	}
+	offset := int64(len(riff))
+
+	foundFmt := false
+	for {
+		var header [8]byte
+		if _, err := io.ReadFull(ws.r, header[:]); err != nil {
+			return ErrInvalidWAV
+		}
+		offset += int64(len(header))
+		id := string(header[0:4])
+		size := int64(binary.LittleEndian.Uint32(header[4:8]))
+
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return ErrInvalidWAV
+			}
+			chunk := make([]byte, size+size%2)
+			if _, err := io.ReadFull(ws.r, chunk); err != nil {
+				return ErrInvalidWAV
+			}
+			offset += int64(len(chunk))
+
+			ws.tag = binary.LittleEndian.Uint16(chunk[0:2])
+			ws.conf.Channels = uint32(binary.LittleEndian.Uint16(chunk[2:4]))
+			ws.conf.SampleRate = binary.LittleEndian.Uint32(chunk[4:8])
+			ws.bits = int(binary.LittleEndian.Uint16(chunk[14:16]))
+
+			// The extensible format stores the real format tag at the start of the sub format GUID
+			if ws.tag == wavExtensible {
+				if size < 26 {
+					return ErrInvalidWAV
+				}
+				ws.tag = binary.LittleEndian.Uint16(chunk[24:26])
+			}
+			foundFmt = true
+		case "data":
+			if !foundFmt {
+				return ErrInvalidWAV
+			}
+			if err := ws.validate(); err != nil {
+				return err
+			}
+
+			ws.dataStart = offset
+			ws.dataSize = size
+			// Streamed files may not know the size of their data
+			if size == 0 || size == math.MaxUint32 {
+				ws.dataSize = -1
+			}
+			return nil
+		default:
+			n, err := io.CopyN(io.Discard, ws.r, size+size%2)
+			if err != nil {
+				return ErrInvalidWAV
+			}
+			offset += n
+		}
+	}
+}
+
+// validate checks the file's format is supported and sets the matching sample format
+func (ws *WAVSource) validate() error {
+	if ws.conf.Channels == 0 || ws.conf.SampleRate == 0 {
+		return ErrInvalidWAV
+	}
+
+	switch {
+	case ws.tag == wavPCM && ws.bits == 8:
+		ws.conf.Format = U8
+	case ws.tag == wavPCM && ws.bits == 16:
+		ws.conf.Format = S16
+	case ws.tag == wavPCM && ws.bits == 24:
+		ws.conf.Format = S24
+	case ws.tag == wavPCM && ws.bits == 32:
+		ws.conf.Format = S32
+	case ws.tag == wavFloat && (ws.bits == 32 || ws.bits == 64):
+		ws.conf.Format = F32
+	default:
+		return ErrWAVFormat
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// encodeWAV creates a WAV file containing the interleaved samples
+func encodeWAV(samples []float64, channels, sampleRate, bits int, float bool) []byte {
+	data := bytes.NewBuffer(nil)
+	for _, s := range samples {
+		switch {
+		case float:
+			binary.Write(data, binary.LittleEndian, float32(s))
+		case bits == 8:
+			data.WriteByte(uint8(s*127 + 128))
+		case bits == 16:
+			binary.Write(data, binary.LittleEndian, int16(s*math.MaxInt16))
+		case bits == 24:
+			v := int32(s * (1<<23 - 1))
+			data.Write([]byte{byte(v), byte(v >> 8), byte(v >> 16)})
+		case bits == 32:
+			binary.Write(data, binary.LittleEndian, int32(s*math.MaxInt32))
+		}
+	}
+
+	tag := uint16(wavPCM)
+	if float {
+		tag = wavFloat
+	}
+	blockAlign := channels * bits / 8
+
+	buf := bytes.NewBuffer(nil)
+	buf.WriteString("RIFF")
+	binary.Write(buf, binary.LittleEndian, uint32(36+data.Len()))
+	buf.WriteString("WAVE")
+	buf.WriteString("fmt ")
+	binary.Write(buf, binary.LittleEndian, uint32(16))
+	binary.Write(buf, binary.LittleEndian, tag)
+	binary.Write(buf, binary.LittleEndian, uint16(channels))
+	binary.Write(buf, binary.LittleEndian, uint32(sampleRate))
+	binary.Write(buf, binary.LittleEndian, uint32(sampleRate*blockAlign))
+	binary.Write(buf, binary.LittleEndian, uint16(blockAlign))
+	binary.Write(buf, binary.LittleEndian, uint16(bits))
+	buf.WriteString("data")
+	binary.Write(buf, binary.LittleEndian, uint32(data.Len()))
+	buf.Write(data.Bytes())
+
+	return buf.Bytes()
+}
+
+// sine generates a sine wave at the given frequency on every channel
+func sine(freq float64, channels, sampleRate int, d time.Duration) []float64 {
+	frames := int(d.Seconds() * float64(sampleRate))
+	samples := make([]float64, 0, frames*channels)
+	for i := 0; i < frames; i++ {
+		s := 0.5 * math.Sin(2*math.Pi*freq*float64(i)/float64(sampleRate))
+		for c := 0; c < channels; c++ {
+			samples = append(samples, s)
+		}
+	}
+	return samples
+}
+
+// streamAll writes all of the source's frames to a buffer
+func streamAll(t *testing.T, src Source) []byte {
+	buf := bytes.NewBuffer(nil)
+	done := make(chan error, 1)
+	assert.Nil(t, src.Start(buf, func(err error) { done <- err }))
+
+	select {
+	case err := <-done:
+		assert.Nil(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("source did not finish")
+	}
+	return buf.Bytes()
+}
+
+func TestWAVSourceDecode(t *testing.T) {
+	samples := sine(440, 2, 44100, 100*time.Millisecond)
+
+	tests := []struct {
+		bits      int
+		float     bool
+		format    SampleFormat
+		tolerance float64
+	}{
+		{8, false, U8, 1.0 / 64},
+		{16, false, S16, 1e-4},
+		{24, false, S24, 1e-6},
+		{32, false, S32, 1e-6},
+		{32, true, F32, 1e-6},
+	}
+
+	for _, tt := range tests {
+		ws, err := NewWAVSource(bytes.NewReader(encodeWAV(samples, 2, 44100, tt.bits, tt.float)))
+		assert.Nil(t, err)
+		assert.Equal(t, Config{Channels: 2, SampleRate: 44100, Format: tt.format}, ws.Format())
+		assert.Equal(t, 100*time.Millisecond, ws.Duration())
+
+		data := streamAll(t, ws)
+		assert.Equal(t, len(samples)*tt.format.Size(), len(data))
+		assert.Equal(t, uint64(len(samples)/2), ws.Frames())
+
+		decoded := tt.format.Decode(nil, data)
+		assert.Equal(t, len(samples), len(decoded))
+		for i := range samples {
+			assert.InDelta(t, samples[i], decoded[i], tt.tolerance, "format: %s", tt.format)
+		}
+	}
+}
+
+func TestWAVSourceInvalid(t *testing.T) {
+	_, err := NewWAVSource(bytes.NewReader([]byte("not a wav file")))
+	assert.Equal(t, ErrInvalidWAV, err)
+
+	_, err = NewWAVSource(bytes.NewReader(encodeWAV(nil, 2, 44100, 12, false)))
+	assert.Equal(t, ErrWAVFormat, err)
+}
+
+func TestWAVSourceRealTime(t *testing.T) {
+	ws, err := NewWAVSource(bytes.NewReader(encodeWAV(sine(440, 2, 44100, 300*time.Millisecond), 2, 44100, 16, false)))
+	assert.Nil(t, err)
+	ws.RealTime = true
+
+	start := time.Now()
+	data := streamAll(t, ws)
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(250*time.Millisecond))
+
+	// Seekable files can be restarted, even while they're being streamed
+	assert.Equal(t, data, streamAll(t, ws))
+	assert.Nil(t, ws.Start(ioutil.Discard, func(error) {}))
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, data, streamAll(t, ws))
+}
@@ -0,0 +1,51 @@
+package audio
+
+import (
+	"errors"
+
+	"currents/internal/dsp"
+)
+
+var ErrInvalidWindow = errors.New("window specified is invalid")
+
+// Window is applied to the audio before it's analysed to reduce spectral
+// leakage, which otherwise makes the loudest frequency jump between bins
+type Window int
+
+const (
+	// Rectangular leaves the audio unchanged
+	Rectangular Window = iota
+	// Hann is a good general purpose window
+	Hann
+	// Hamming has a narrower main lobe than Hann but more leakage far from the peak
+	Hamming
+	// BlackmanHarris has very little leakage at the cost of a wider peak
+	BlackmanHarris
+	// FlatTop measures the amplitude of peaks accurately but has the widest peak
+	FlatTop
+)
+
+// Windows lists every window in the order they're declared
+var Windows = []Window{Rectangular, Hann, Hamming, BlackmanHarris, FlatTop}
+
+func (w Window) String() string {
+	return [...]string{"Rectangular", "Hann", "Hamming", "Blackman-Harris", "Flat-top"}[w]
+}
+
+// coefficients returns the coefficients of the window of length n appended to dst
+func (w Window) coefficients(dst []float32, n int) []float32 {
+	switch w {
+	case Rectangular:
+		return dsp.Rectangular(dst, n)
+	case Hann:
+		return dsp.Hann(dst, n)
+	case Hamming:
+		return dsp.Hamming(dst, n)
+	case BlackmanHarris:
+		return dsp.BlackmanHarris(dst, n)
+	case FlatTop:
+		return dsp.FlatTop(dst, n)
+	}
+
+	panic(ErrInvalidWindow)
+}
@@ -0,0 +1,193 @@
+package complex
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"gioui.org/f32"
+	"gioui.org/gesture"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+
+	"currents/pkg/audio"
+)
+
+// The highest frequency shown on the curve editor
+const curveMaxFreq = 5000
+
+// CurveEditor lets the user drag the control points of an audio.Curve,
+// clicking away from a point adds a new one
+type CurveEditor struct {
+	curve    *audio.Curve
+	drag     gesture.Drag
+	selected int
+	changed  bool
+
+	removeBtn widget.Clickable
+	resetBtn  widget.Clickable
+}
+
+func NewCurveEditor(curve *audio.Curve) *CurveEditor {
+	return &CurveEditor{curve: curve, selected: -1}
+}
+
+// Changed reports whether the curve was edited since the last call
+func (ce *CurveEditor) Changed() bool {
+	changed := ce.changed
+	ce.changed = false
+	return changed
+}
+
+func (ce *CurveEditor) Layout(th *material.Theme) layout.Widget {
+	return func(gtx layout.Context) layout.Dimensions {
+		c := *ce.curve
+		if ce.removeBtn.Clicked() && ce.selected >= 0 && len(c) > 2 {
+			c = append(c[:ce.selected], c[ce.selected+1:]...)
+			ce.selected = -1
+			ce.changed = true
+		}
+		if ce.resetBtn.Clicked() {
+			c = audio.DefaultCurve()
+			ce.selected = -1
+			ce.changed = true
+		}
+		*ce.curve = c
+
+		label := "Click to add a point, drag to move it"
+		if ce.selected >= 0 {
+			p := c[ce.selected]
+			label = fmt.Sprintf("%.0f Hz at %.2f", p.Freq, p.Pos)
+		}
+
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+			layout.Rigid(ce.graph(th)),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						if ce.selected < 0 || len(c) <= 2 {
+							gtx = gtx.Disabled()
+						}
+						return material.Button(th, &ce.removeBtn, "Remove").Layout(gtx)
+					}),
+					layout.Rigid(layout.Spacer{Width: unit.Dp(5)}.Layout),
+					layout.Rigid(material.Button(th, &ce.resetBtn, "Reset").Layout),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return layout.UniformInset(unit.Dp(8)).Layout(gtx,
+							material.Body2(th, label).Layout,
+						)
+					}),
+				)
+			}),
+		)
+	}
+}
+
+// graph draws the curve and moves its points with the pointer
+func (ce *CurveEditor) graph(th *material.Theme) layout.Widget {
+	return func(gtx layout.Context) layout.Dimensions {
+		size := image.Point{X: gtx.Constraints.Max.X, Y: gtx.Px(unit.Dp(120))}
+		width, height := float32(size.X), float32(size.Y)
+		radius := float32(gtx.Px(unit.Dp(5)))
+
+		toPoint := func(freq, pos float64) f32.Point {
+			return f32.Point{X: float32(freq/curveMaxFreq) * width, Y: float32(1-pos) * height}
+		}
+		fromPoint := func(p f32.Point) (freq, pos float64) {
+			freq = math.Max(0, math.Min(curveMaxFreq, float64(p.X/width)*curveMaxFreq))
+			pos = math.Max(0, math.Min(1, 1-float64(p.Y/height)))
+			return freq, pos
+		}
+
+		c := *ce.curve
+		for _, e := range ce.drag.Events(gtx.Metric, gtx, gesture.Both) {
+			switch e.Type {
+			case pointer.Press:
+				ce.selected = -1
+				for i, p := range c {
+					pt := toPoint(p.Freq, p.Pos)
+					if math.Hypot(float64(pt.X-e.Position.X), float64(pt.Y-e.Position.Y)) <= float64(2*radius) {
+						ce.selected = i
+					}
+				}
+				if ce.selected < 0 {
+					// Points must have different frequencies for the curve to be valid, so
+					// clicks as close to a point's frequency as dragging allows are ignored
+					freq, pos := fromPoint(e.Position)
+					taken := false
+					for _, p := range c {
+						if math.Abs(p.Freq-freq) < 1 {
+							taken = true
+						}
+					}
+					if taken {
+						break
+					}
+					c = append(c, c[0])
+					c[len(c)-1].Freq, c[len(c)-1].Pos = freq, pos
+					c.Sort()
+					for i, p := range c {
+						if p.Freq == freq && p.Pos == pos {
+							ce.selected = i
+						}
+					}
+					ce.changed = true
+				}
+			case pointer.Drag:
+				if ce.selected < 0 {
+					break
+				}
+				// Keep the point between its neighbours so the curve stays sorted
+				freq, pos := fromPoint(e.Position)
+				low, high := 0.0, float64(curveMaxFreq)
+				if ce.selected > 0 {
+					low = c[ce.selected-1].Freq + 1
+				}
+				if ce.selected < len(c)-1 {
+					high = c[ce.selected+1].Freq - 1
+				}
+				c[ce.selected].Freq = math.Max(low, math.Min(high, freq))
+				c[ce.selected].Pos = pos
+				ce.changed = true
+			}
+		}
+		*ce.curve = c
+
+		defer op.Save(gtx.Ops).Load()
+		background := th.Palette.Fg
+		background.A = 0x20
+		paint.FillShape(gtx.Ops, background, clip.Rect{Max: size}.Op())
+
+		// The curve is flat before its first and after its last point
+		var path clip.Path
+		path.Begin(gtx.Ops)
+		if len(c) > 0 {
+			path.MoveTo(f32.Point{Y: toPoint(0, c[0].Pos).Y})
+			for _, p := range c {
+				path.LineTo(toPoint(p.Freq, p.Pos))
+			}
+			path.LineTo(f32.Point{X: width, Y: toPoint(0, c[len(c)-1].Pos).Y})
+		}
+		stroke := clip.Stroke{Path: path.End(), Style: clip.StrokeStyle{Width: float32(gtx.Px(unit.Dp(2)))}}
+		paint.FillShape(gtx.Ops, th.Palette.ContrastBg, stroke.Op())
+
+		for i, p := range c {
+			col := th.Palette.ContrastBg
+			if i == ce.selected {
+				col = th.Palette.Fg
+			}
+			paint.FillShape(gtx.Ops, col, clip.Circle{Center: toPoint(p.Freq, p.Pos), Radius: radius}.Op(gtx.Ops))
+		}
+
+		pointer.Rect(image.Rectangle{Max: size}).Add(gtx.Ops)
+		ce.drag.Add(gtx.Ops)
+
+		return layout.Dimensions{Size: size}
+	}
+}
@@ -0,0 +1,207 @@
+package complex
+
+import (
+	"fmt"
+	"strings"
+
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+
+	"currents/internal/xgio"
+	"currents/internal/xmaterial"
+	"currents/pkg/audio"
+)
+
+// Prefix of the feature items which route the energy of a band
+const bandItem = "Band: "
+
+// routeRow holds the widgets which edit one route
+type routeRow struct {
+	features     xgio.Combo
+	parameters   xgio.Combo
+	scaleSlider  widget.Float
+	offsetSlider widget.Float
+	invert       widget.Bool
+	removeBtn    widget.Clickable
+}
+
+// RoutesEditor lets the user add, edit and remove the routes
+// from the features of the audio to the parameters of the colour
+type RoutesEditor struct {
+	routes  *audio.Routes
+	bands   []string
+	rows    []*routeRow
+	changed bool
+
+	addBtn   widget.Clickable
+	resetBtn widget.Clickable
+}
+
+// NewRoutesEditor creates an editor for the routes, bands are
+// the names of the bands whose energy can be routed
+func NewRoutesEditor(routes *audio.Routes, bands []string) *RoutesEditor {
+	re := &RoutesEditor{routes: routes, bands: bands}
+	re.loadRows()
+	return re
+}
+
+// Changed reports whether the routes were edited since the last call
+func (re *RoutesEditor) Changed() bool {
+	changed := re.changed
+	re.changed = false
+	return changed
+}
+
+// loadRows creates a row of widgets for each route
+func (re *RoutesEditor) loadRows() {
+	re.rows = re.rows[:0]
+	for _, r := range *re.routes {
+		re.rows = append(re.rows, re.newRow(r))
+	}
+}
+
+func (re *RoutesEditor) newRow(r audio.Route) *routeRow {
+	features := make([]string, 0, len(audio.Features)+len(re.bands))
+	for _, ft := range audio.Features {
+		if ft != audio.BandFeature {
+			features = append(features, ft.String())
+		}
+	}
+	for _, b := range re.bands {
+		features = append(features, bandItem+b)
+	}
+	parameters := make([]string, 0, len(audio.Parameters))
+	for _, p := range audio.Parameters {
+		parameters = append(parameters, p.String())
+	}
+
+	row := &routeRow{
+		features:   xgio.MakeCombo(features, "Select a feature"),
+		parameters: xgio.MakeCombo(parameters, "Select a parameter"),
+	}
+	// Bands which have been removed since the route was saved are still shown
+	if err := row.features.SelectItem(featureItem(r)); err != nil {
+		row.features.Add(featureItem(r))
+		row.features.SelectItem(featureItem(r))
+	}
+	row.parameters.SelectItem(r.Parameter.String())
+	row.scaleSlider.Value = float32(r.Scale)
+	row.offsetSlider.Value = float32(r.Offset)
+	row.invert.Value = r.Invert
+	return row
+}
+
+// featureItem returns the item of the feature combo for the route
+func featureItem(r audio.Route) string {
+	if r.Feature == audio.BandFeature {
+		return bandItem + r.Band
+	}
+	return r.Feature.String()
+}
+
+// parseFeatureItem returns the feature and band of an item of the feature combo
+func parseFeatureItem(item string) (audio.Feature, string) {
+	if strings.HasPrefix(item, bandItem) {
+		return audio.BandFeature, strings.TrimPrefix(item, bandItem)
+	}
+	for _, ft := range audio.Features {
+		if ft.String() == item {
+			return ft, ""
+		}
+	}
+	return audio.FrequencyFeature, ""
+}
+
+// update copies the edits of the widgets into the routes
+func (re *RoutesEditor) update() {
+	routes := *re.routes
+	for i := 0; i < len(re.rows); i++ {
+		row, r := re.rows[i], &routes[i]
+		if row.removeBtn.Clicked() {
+			routes = append(routes[:i], routes[i+1:]...)
+			re.rows = append(re.rows[:i], re.rows[i+1:]...)
+			re.changed = true
+			i--
+			continue
+		}
+
+		if row.features.HasSelected() && row.features.SelectedText() != featureItem(*r) {
+			r.Feature, r.Band = parseFeatureItem(row.features.SelectedText())
+			re.changed = true
+		}
+		if row.parameters.HasSelected() && row.parameters.SelectedText() != r.Parameter.String() {
+			for _, p := range audio.Parameters {
+				if p.String() == row.parameters.SelectedText() {
+					r.Parameter = p
+				}
+			}
+			re.changed = true
+		}
+		if row.scaleSlider.Changed() || row.offsetSlider.Changed() {
+			r.Scale = float64(row.scaleSlider.Value)
+			r.Offset = float64(row.offsetSlider.Value)
+			re.changed = true
+		}
+		if row.invert.Changed() {
+			r.Invert = row.invert.Value
+			re.changed = true
+		}
+	}
+
+	if re.addBtn.Clicked() {
+		r := audio.Route{Feature: audio.LoudnessFeature, Parameter: audio.Brightness, Scale: 1}
+		routes = append(routes, r)
+		re.rows = append(re.rows, re.newRow(r))
+		re.changed = true
+	}
+	*re.routes = routes
+
+	if re.resetBtn.Clicked() {
+		*re.routes = audio.DefaultRoutes()
+		re.loadRows()
+		re.changed = true
+	}
+}
+
+func (re *RoutesEditor) Layout(th *material.Theme) layout.Widget {
+	return func(gtx layout.Context) layout.Dimensions {
+		re.update()
+
+		children := make([]layout.FlexChild, 0, len(re.rows)+1)
+		for _, row := range re.rows {
+			children = append(children, layout.Rigid(row.layout(th)))
+		}
+		children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+				layout.Rigid(material.Button(th, &re.addBtn, "Add").Layout),
+				layout.Rigid(layout.Spacer{Width: unit.Dp(5)}.Layout),
+				layout.Rigid(material.Button(th, &re.resetBtn, "Reset").Layout),
+			)
+		}))
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+	}
+}
+
+func (row *routeRow) layout(th *material.Theme) layout.Widget {
+	return func(gtx layout.Context) layout.Dimensions {
+		return layout.Inset{Bottom: unit.Dp(10)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+						layout.Rigid(xmaterial.Combo(th, &row.features).Layout),
+						layout.Rigid(material.Body2(th, " → ").Layout),
+						layout.Rigid(xmaterial.Combo(th, &row.parameters).Layout),
+						layout.Rigid(layout.Spacer{Width: unit.Dp(5)}.Layout),
+						layout.Rigid(material.CheckBox(th, &row.invert, "Invert").Layout),
+						layout.Rigid(layout.Spacer{Width: unit.Dp(5)}.Layout),
+						layout.Rigid(material.Button(th, &row.removeBtn, "Remove").Layout),
+					)
+				}),
+				layout.Rigid(labelledSlider(th, &row.scaleSlider, -2, 2, fmt.Sprintf("Scale %.2f", row.scaleSlider.Value))),
+				layout.Rigid(labelledSlider(th, &row.offsetSlider, -1, 1, fmt.Sprintf("Offset %.2f", row.offsetSlider.Value))),
+			)
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package complex
 
 import (
+	"context"
+	"fmt"
 	"image"
 	"time"
 
@@ -26,14 +28,20 @@ type Visualisation struct {
 	audio           *audio.Audio
 	audioConfig     *audio.Config
 	currentColour   colorful.Color
+	currentStereo   audio.StereoColour
 	devices         []audio.Device
 	fft             *audio.FFT
 	gradients       *audio.Gradients
+	curve           *audio.Curve
+	routes          *audio.Routes
 	currentDevice   string
 	currentGradient string
+	currentWindow   string
 	started         bool
 	drawMode        *audio.InterpolateMode
-	defaultDamp     float32
+	stats           audio.Stats
+	tempo           audio.Tempo
+	cancel          context.CancelFunc // Stops the current source, nil if stopped
 
 	// Session
 	session *session.Server
@@ -43,19 +51,52 @@ type Visualisation struct {
 	stopBtn           widget.Clickable
 	gradientsCombobox xgio.Combo
 	devicesCombobox   xgio.Combo
-	dampCheckbox      widget.Bool
+	windowsCombobox   xgio.Combo
+	tempoCheckbox     widget.Bool
+	brightCheckbox    widget.Bool
+	satCheckbox       widget.Bool
+	floorSlider       widget.Float
+	ceilingSlider     widget.Float
+	curveSlider       widget.Float
+	adaptiveCheckbox  widget.Bool
+	attackSlider      widget.Float
+	releaseSlider     widget.Float
+	gateCheckbox      widget.Bool
+	separateCheckbox  widget.Bool
+	pulseSlider       widget.Float
+	thresholdSlider   widget.Float
+	holdSlider        widget.Float
+	idleEffects       widget.Enum
 	drawModes         widget.Enum
-	dampSlider        widget.Float
-	dampReset         widget.Clickable
+	beatModes         widget.Enum
+	pitchModes        widget.Enum
+	channelModes      widget.Enum
+	mappings          widget.Enum
+	scales            widget.Enum
+	descriptors       widget.Enum
+	minFreqSlider     widget.Float
+	maxFreqSlider     widget.Float
+	curveEditor       *CurveEditor
+	routesEditor      *RoutesEditor
+	paletteCheckbox   widget.Bool
+	smoothFilters     widget.Enum
+	smoothTargets     widget.Enum
+	smoothTimeSlider  widget.Float
+	cutoffSlider      widget.Float
+	betaSlider        widget.Float
+	rateSlider        widget.Float
 }
 
-func NewVisualisation(gradients *audio.Gradients, redraw func(), drawMode *audio.InterpolateMode, server *session.Server) *Visualisation {
+func NewVisualisation(gradients *audio.Gradients, curve *audio.Curve, routes *audio.Routes, redraw func(), drawMode *audio.InterpolateMode, server *session.Server) *Visualisation {
 	v := &Visualisation{
 		audio:             audio.MustCreateNewAudio(),
 		audioConfig:       audio.DefaultConfig(),
 		gradientsCombobox: xgio.Combo{},
 		devicesCombobox:   xgio.Combo{},
 		gradients:         gradients,
+		curve:             curve,
+		curveEditor:       NewCurveEditor(curve),
+		routes:            routes,
 		session:           server,
 		drawMode:          drawMode,
 	}
@@ -81,31 +122,80 @@ func NewVisualisation(gradients *audio.Gradients, redraw func(), drawMode *audio
 
 	// Create the fft context and start waiting for colours to input
 	v.fft = audio.MustCreateNewFFT(v.audioConfig)
-	v.dampCheckbox.Value = v.fft.Damp
 	g := v.gradients.Get(v.gradientsCombobox.SelectedText())
 	v.fft.Gradient = &g
 	v.fft.DrawMode = *drawMode
 	v.drawModes.Value = drawMode.String()
-	v.dampSlider.Value = float32(v.fft.SampleRate.Milliseconds())
-	v.defaultDamp = v.dampSlider.Value
+	v.beatModes.Value = v.fft.BeatMode.String()
+	v.pitchModes.Value = v.fft.PitchMode.String()
+	v.channelModes.Value = v.fft.ChannelMode.String()
+	v.mappings.Value = v.fft.Mapping.String()
+	v.scales.Value = v.fft.Scale.String()
+	v.descriptors.Value = v.fft.Descriptor.String()
+	v.minFreqSlider.Value = float32(v.fft.MinFreq)
+	v.maxFreqSlider.Value = float32(v.fft.MaxFreq)
+	v.fft.Curve = append(audio.Curve(nil), *curve...)
+	v.fft.Routes = append(audio.Routes(nil), *routes...)
+	bands := make([]string, 0, len(v.fft.Bands))
+	for _, b := range v.fft.Bands {
+		bands = append(bands, b.Name)
+	}
+	v.routesEditor = NewRoutesEditor(routes, bands)
+	v.floorSlider.Value = float32(v.fft.Dynamics.Floor)
+	v.ceilingSlider.Value = float32(v.fft.Dynamics.Ceiling)
+	v.curveSlider.Value = float32(v.fft.Dynamics.Curve)
+	v.attackSlider.Value = float32(v.fft.AGC.Attack.Milliseconds())
+	v.releaseSlider.Value = float32(v.fft.AGC.Release.Seconds())
+	v.gateCheckbox.Value = v.fft.Gate.Enabled
+	v.separateCheckbox.Value = v.fft.Separation.Enabled
+	v.pulseSlider.Value = float32(v.fft.Separation.Pulse)
+	v.thresholdSlider.Value = float32(v.fft.Gate.Threshold)
+	v.holdSlider.Value = float32(v.fft.Gate.Hold.Seconds())
+	v.idleEffects.Value = v.fft.Gate.Effect.String()
+	v.smoothFilters.Value = v.fft.Smoothing.Filter.String()
+	v.smoothTargets.Value = v.fft.Smoothing.Target.String()
+	v.smoothTimeSlider.Value = float32(v.fft.Smoothing.Time.Milliseconds())
+	v.cutoffSlider.Value = float32(v.fft.Smoothing.MinCutoff)
+	v.betaSlider.Value = float32(v.fft.Smoothing.Beta)
+	v.rateSlider.Value = float32(v.fft.Smoothing.Rate)
+
+	// Load the possible windows
+	windowList := make([]string, 0, len(audio.Windows))
+	for _, w := range audio.Windows {
+		windowList = append(windowList, w.String())
+	}
+	v.windowsCombobox = xgio.MakeCombo(windowList, "Select a window")
+	v.windowsCombobox.SelectItem(v.fft.Window.String())
+	v.currentWindow = v.windowsCombobox.SelectedText()
+
+	go func() {
+		err := v.fft.Run(context.Background())
+		log.Fatal().Err(err).Msg("fft stopped")
+	}()
 
 	go func() {
-	loop:
+		statsTicker := time.NewTicker(time.Second)
+		defer statsTicker.Stop()
+
 		for {
 			select {
-			case err := <-v.audio.Done:
-				log.Debug().Err(err).Msg("audio capture done")
-			case err := <-v.fft.Done:
-				log.Debug().Err(err).Msg("fft done")
-				if err != nil {
-					log.Fatal().Err(err).Msg("fft buffer failed")
-				}
-				break loop
+			case <-statsTicker.C:
+				v.stats = v.fft.Stats()
+				v.tempo = v.fft.Tempo()
+				log.Trace().Str("stats", v.stats.String()).Msg("fft stats")
 			case hue := <-v.fft.Hues:
 				v.currentColour = hue
-				v.session.SendColour(hue)
+				if v.mono() {
+					v.session.SendColour(hue)
+				}
 				redraw()
 				log.Trace().Str("hue", hue.Hex()).Msg("fft hue received")
+			case stereo := <-v.fft.StereoHues:
+				v.currentStereo = stereo
+				if !v.mono() {
+					v.session.SendColours(stereo.Left, stereo.Right)
+				}
+				log.Trace().Str("left", stereo.Left.Hex()).Str("right", stereo.Right.Hex()).Msg("fft stereo hues received")
 			}
 		}
 	}()
@@ -113,6 +203,14 @@ func NewVisualisation(gradients *audio.Gradients, redraw func(), drawMode *audio
 	return v
 }
 
+// mono reports whether the channels are visualised together, it locks
+// the fft since it's called while the settings may be changed
+func (v *Visualisation) mono() bool {
+	v.fft.Lock()
+	defer v.fft.Unlock()
+	return v.fft.ChannelMode == audio.Mono
+}
+
 func (v *Visualisation) Layout(th *material.Theme) layout.Widget {
 	return func(gtx layout.Context) layout.Dimensions {
 		// Handles logic i.e. controlling the audio context
@@ -143,30 +241,168 @@ func (v *Visualisation) Layout(th *material.Theme) layout.Widget {
 					layout.Rigid(material.RadioButton(th, &v.drawModes, audio.Blended.String(), audio.Blended.String()).Layout),
 					layout.Rigid(material.RadioButton(th, &v.drawModes, audio.Blocky.String(), audio.Blocky.String()).Layout),
 					layout.Rigid(layout.Spacer{Height: unit.Dp(10)}.Layout),
-					layout.Rigid(material.H6(th, "Device:").Layout),
-					layout.Rigid(xmaterial.Combo(th, &v.devicesCombobox).Layout),
+					layout.Rigid(material.H6(th, "Mapping:").Layout),
+					layout.Rigid(material.RadioButton(th, &v.mappings, audio.FrequencyRamp.String(), audio.FrequencyRamp.String()).Layout),
+					layout.Rigid(material.RadioButton(th, &v.mappings, audio.Chroma.String(), audio.Chroma.String()).Layout),
+					layout.Rigid(material.RadioButton(th, &v.mappings, audio.Routed.String(), audio.Routed.String()).Layout),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						if v.fft.Mapping != audio.Chroma {
+							gtx = gtx.Disabled()
+						}
+						return material.CheckBox(th, &v.paletteCheckbox, "Scriabin palette").Layout(gtx)
+					}),
+					layout.Rigid(layout.Spacer{Height: unit.Dp(10)}.Layout),
+					layout.Rigid(material.H6(th, "Routes:").Layout),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						if v.fft.Mapping != audio.Routed {
+							gtx = gtx.Disabled()
+						}
+						return v.routesEditor.Layout(th)(gtx)
+					}),
 					layout.Rigid(layout.Spacer{Height: unit.Dp(10)}.Layout),
-					layout.Rigid(material.H6(th, "Damping:").Layout),
-					layout.Rigid(material.CheckBox(th, &v.dampCheckbox, "On/Off").Layout),
+					layout.Rigid(material.H6(th, "Descriptor:").Layout),
 					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-						if !(v.dampCheckbox.Value) {
+						// The routes use the frequency and its scale too
+						if v.fft.Mapping == audio.Chroma {
 							gtx = gtx.Disabled()
 						}
-						return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
-							layout.Flexed(1, material.Slider(th, &v.dampSlider, 1, 600).Layout),
+						return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+							layout.Rigid(material.RadioButton(th, &v.descriptors, audio.PeakFrequency.String(), audio.PeakFrequency.String()).Layout),
+							layout.Rigid(material.RadioButton(th, &v.descriptors, audio.Centroid.String(), audio.Centroid.String()).Layout),
+							layout.Rigid(material.RadioButton(th, &v.descriptors, audio.Spread.String(), audio.Spread.String()).Layout),
+							layout.Rigid(material.RadioButton(th, &v.descriptors, audio.Flux.String(), audio.Flux.String()).Layout),
+							layout.Rigid(material.RadioButton(th, &v.descriptors, audio.Rolloff.String(), audio.Rolloff.String()).Layout),
+							layout.Rigid(material.RadioButton(th, &v.descriptors, audio.Flatness.String(), audio.Flatness.String()).Layout),
+						)
+					}),
+					layout.Rigid(layout.Spacer{Height: unit.Dp(10)}.Layout),
+					layout.Rigid(material.H6(th, "Scale:").Layout),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						if v.fft.Mapping == audio.Chroma {
+							gtx = gtx.Disabled()
+						}
+						return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+							layout.Rigid(material.RadioButton(th, &v.scales, audio.CurveScale.String(), audio.CurveScale.String()).Layout),
+							layout.Rigid(material.RadioButton(th, &v.scales, audio.LogScale.String(), audio.LogScale.String()).Layout),
+							layout.Rigid(material.RadioButton(th, &v.scales, audio.MelScale.String(), audio.MelScale.String()).Layout),
+							layout.Rigid(material.RadioButton(th, &v.scales, audio.BarkScale.String(), audio.BarkScale.String()).Layout),
+							layout.Rigid(labelledSlider(th, &v.minFreqSlider, 20, 500, fmt.Sprintf("Min %.0f Hz", v.minFreqSlider.Value))),
+							layout.Rigid(labelledSlider(th, &v.maxFreqSlider, 500, 20000, fmt.Sprintf("Max %.0f Hz", v.maxFreqSlider.Value))),
 							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-								return layout.UniformInset(unit.Dp(8)).Layout(gtx,
-									material.Body2(th, "Strength").Layout,
+								if v.fft.Scale != audio.CurveScale {
+									gtx = gtx.Disabled()
+								}
+								return v.curveEditor.Layout(th)(gtx)
+							}),
+						)
+					}),
+					layout.Rigid(layout.Spacer{Height: unit.Dp(10)}.Layout),
+					layout.Rigid(material.H6(th, "Dynamics:").Layout),
+					layout.Rigid(material.CheckBox(th, &v.brightCheckbox, "Brightness").Layout),
+					layout.Rigid(material.CheckBox(th, &v.satCheckbox, "Saturation").Layout),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						if !(v.brightCheckbox.Value || v.satCheckbox.Value) {
+							gtx = gtx.Disabled()
+						}
+						return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+								if v.adaptiveCheckbox.Value {
+									gtx = gtx.Disabled()
+								}
+								return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+									layout.Rigid(labelledSlider(th, &v.floorSlider, -90, 0, fmt.Sprintf("Floor %.0f dB", v.floorSlider.Value))),
+									layout.Rigid(labelledSlider(th, &v.ceilingSlider, -60, 0, fmt.Sprintf("Ceiling %.0f dB", v.ceilingSlider.Value))),
 								)
 							}),
+							layout.Rigid(labelledSlider(th, &v.curveSlider, 0.25, 4, fmt.Sprintf("Curve %.2f", v.curveSlider.Value))),
+							layout.Rigid(material.CheckBox(th, &v.adaptiveCheckbox, "Adaptive").Layout),
+							layout.Rigid(labelledSlider(th, &v.attackSlider, 5, 500, fmt.Sprintf("Attack %.0f ms", v.attackSlider.Value))),
+							layout.Rigid(labelledSlider(th, &v.releaseSlider, 0.5, 20, fmt.Sprintf("Release %.1f s", v.releaseSlider.Value))),
 						)
 					}),
+					layout.Rigid(layout.Spacer{Height: unit.Dp(10)}.Layout),
+					layout.Rigid(material.H6(th, "Separation:").Layout),
+					layout.Rigid(material.CheckBox(th, &v.separateCheckbox, "Drums pulse the brightness").Layout),
 					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-						if !(v.dampCheckbox.Value) {
+						if !v.separateCheckbox.Value {
 							gtx = gtx.Disabled()
 						}
-						return material.Button(th, &v.dampReset, "Reset Damping").Layout(gtx)
+						return labelledSlider(th, &v.pulseSlider, 0, 1, fmt.Sprintf("Pulse %.2f", v.pulseSlider.Value))(gtx)
+					}),
+					layout.Rigid(layout.Spacer{Height: unit.Dp(10)}.Layout),
+					layout.Rigid(material.H6(th, "Silence:").Layout),
+					layout.Rigid(material.CheckBox(th, &v.gateCheckbox, "Fade when silent").Layout),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						if !v.gateCheckbox.Value {
+							gtx = gtx.Disabled()
+						}
+						return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+							layout.Rigid(labelledSlider(th, &v.thresholdSlider, -90, -20, fmt.Sprintf("Threshold %.0f dB", v.thresholdSlider.Value))),
+							layout.Rigid(labelledSlider(th, &v.holdSlider, 0.5, 10, fmt.Sprintf("Hold %.1f s", v.holdSlider.Value))),
+							layout.Rigid(material.RadioButton(th, &v.idleEffects, audio.FadeToBlack.String(), audio.FadeToBlack.String()).Layout),
+							layout.Rigid(material.RadioButton(th, &v.idleEffects, audio.IdleColour.String(), audio.IdleColour.String()).Layout),
+							layout.Rigid(material.RadioButton(th, &v.idleEffects, audio.Breathe.String(), audio.Breathe.String()).Layout),
+							layout.Rigid(material.RadioButton(th, &v.idleEffects, audio.Rainbow.String(), audio.Rainbow.String()).Layout),
+						)
+					}),
+					layout.Rigid(layout.Spacer{Height: unit.Dp(10)}.Layout),
+					layout.Rigid(material.H6(th, "Beats:").Layout),
+					layout.Rigid(material.RadioButton(th, &v.beatModes, audio.FollowFrequency.String(), audio.FollowFrequency.String()).Layout),
+					layout.Rigid(material.RadioButton(th, &v.beatModes, audio.StepGradient.String(), audio.StepGradient.String()).Layout),
+					layout.Rigid(material.RadioButton(th, &v.beatModes, audio.Flash.String(), audio.Flash.String()).Layout),
+					layout.Rigid(material.RadioButton(th, &v.beatModes, audio.CycleGradient.String(), audio.CycleGradient.String()).Layout),
+					layout.Rigid(material.CheckBox(th, &v.tempoCheckbox, "Sync to tempo").Layout),
+					layout.Rigid(layout.Spacer{Height: unit.Dp(10)}.Layout),
+					layout.Rigid(material.H6(th, "Device:").Layout),
+					layout.Rigid(xmaterial.Combo(th, &v.devicesCombobox).Layout),
+					layout.Rigid(layout.Spacer{Height: unit.Dp(10)}.Layout),
+					layout.Rigid(material.H6(th, "Window:").Layout),
+					layout.Rigid(xmaterial.Combo(th, &v.windowsCombobox).Layout),
+					layout.Rigid(layout.Spacer{Height: unit.Dp(10)}.Layout),
+					layout.Rigid(material.H6(th, "Channels:").Layout),
+					layout.Rigid(material.RadioButton(th, &v.channelModes, audio.Mono.String(), audio.Mono.String()).Layout),
+					layout.Rigid(material.RadioButton(th, &v.channelModes, audio.Stereo.String(), audio.Stereo.String()).Layout),
+					layout.Rigid(material.RadioButton(th, &v.channelModes, audio.MidSide.String(), audio.MidSide.String()).Layout),
+					layout.Rigid(layout.Spacer{Height: unit.Dp(10)}.Layout),
+					layout.Rigid(material.H6(th, "Pitch:").Layout),
+					layout.Rigid(material.RadioButton(th, &v.pitchModes, audio.PeakBin.String(), audio.PeakBin.String()).Layout),
+					layout.Rigid(material.RadioButton(th, &v.pitchModes, audio.Parabolic.String(), audio.Parabolic.String()).Layout),
+					layout.Rigid(material.RadioButton(th, &v.pitchModes, audio.YIN.String(), audio.YIN.String()).Layout),
+					layout.Rigid(layout.Spacer{Height: unit.Dp(10)}.Layout),
+					layout.Rigid(material.H6(th, "Smoothing:").Layout),
+					layout.Rigid(material.RadioButton(th, &v.smoothFilters, audio.NoSmoothing.String(), audio.NoSmoothing.String()).Layout),
+					layout.Rigid(material.RadioButton(th, &v.smoothFilters, audio.MovingAverage.String(), audio.MovingAverage.String()).Layout),
+					layout.Rigid(material.RadioButton(th, &v.smoothFilters, audio.Spring.String(), audio.Spring.String()).Layout),
+					layout.Rigid(material.RadioButton(th, &v.smoothFilters, audio.OneEuro.String(), audio.OneEuro.String()).Layout),
+					layout.Rigid(material.RadioButton(th, &v.smoothFilters, audio.SlewLimiter.String(), audio.SlewLimiter.String()).Layout),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						var sliders []layout.FlexChild
+						switch v.fft.Smoothing.Filter {
+						case audio.NoSmoothing:
+							return layout.Dimensions{}
+						case audio.MovingAverage, audio.Spring:
+							sliders = append(sliders,
+								layout.Rigid(labelledSlider(th, &v.smoothTimeSlider, 10, 1000, fmt.Sprintf("Time %.0f ms", v.smoothTimeSlider.Value))),
+							)
+						case audio.OneEuro:
+							sliders = append(sliders,
+								layout.Rigid(labelledSlider(th, &v.cutoffSlider, 0.1, 10, fmt.Sprintf("Cutoff %.1f Hz", v.cutoffSlider.Value))),
+								layout.Rigid(labelledSlider(th, &v.betaSlider, 0, 50, fmt.Sprintf("Beta %.1f", v.betaSlider.Value))),
+							)
+						case audio.SlewLimiter:
+							sliders = append(sliders,
+								layout.Rigid(labelledSlider(th, &v.rateSlider, 0.1, 10, fmt.Sprintf("Rate %.1f /s", v.rateSlider.Value))),
+							)
+						}
+						sliders = append(sliders,
+							layout.Rigid(material.RadioButton(th, &v.smoothTargets, audio.SmoothFrequency.String(), audio.SmoothFrequency.String()).Layout),
+							layout.Rigid(material.RadioButton(th, &v.smoothTargets, audio.SmoothColour.String(), audio.SmoothColour.String()).Layout),
+						)
+						return layout.Flex{Axis: layout.Vertical}.Layout(gtx, sliders...)
 					}),
+					layout.Rigid(layout.Spacer{Height: unit.Dp(10)}.Layout),
+					layout.Rigid(material.Caption(th, v.tempo.String()).Layout),
+					layout.Rigid(material.Caption(th, v.stats.String()).Layout),
 				)
 			},
 		)
@@ -175,6 +411,16 @@ func (v *Visualisation) Layout(th *material.Theme) layout.Widget {
 			func(gtx layout.Context) layout.Dimensions {
 				dr := image.Rectangle{Max: gtx.Constraints.Min}
 				defer op.Save(gtx.Ops).Load()
+				if v.started && v.fft.ChannelMode != audio.Mono {
+					// Each half of the box shows one side like the strip does
+					left, right := dr, dr
+					left.Max.X, right.Min.X = dr.Max.X/2, dr.Max.X/2
+					paint.FillShape(gtx.Ops, convertNRGBA(v.currentStereo.Left), clip.Rect(left).Op())
+					paint.FillShape(gtx.Ops, convertNRGBA(v.currentStereo.Right), clip.Rect(right).Op())
+					return layout.Dimensions{
+						Size: gtx.Constraints.Max,
+					}
+				}
 				paint.ColorOp{Color: convertNRGBA(v.currentColour)}.Add(gtx.Ops)
 				clip.Rect(dr).Add(gtx.Ops)
 				paint.PaintOp{}.Add(gtx.Ops)
@@ -211,6 +457,16 @@ func (v *Visualisation) handleLogic() {
 		v.stopCapture()
 	}
 
+	// Device
+	if v.started && v.devicesCombobox.SelectedText() != v.currentDevice {
+		v.currentDevice = v.devicesCombobox.SelectedText()
+		v.startCapture()
+	}
+
+	// The fft reads the settings while it analyses the audio
+	v.fft.Lock()
+	defer v.fft.Unlock()
+
 	// Gradient
 	if v.gradientsCombobox.SelectedText() != v.currentGradient {
 		v.currentGradient = v.gradientsCombobox.SelectedText()
@@ -229,26 +485,153 @@ func (v *Visualisation) handleLogic() {
 		}
 
 	}
-
-	// Device
-	if v.started && v.devicesCombobox.SelectedText() != v.currentDevice {
-		v.currentDevice = v.devicesCombobox.SelectedText()
-		v.startCapture()
+	if v.mappings.Changed() {
+		for _, cm := range audio.ColourMappings {
+			if cm.String() == v.mappings.Value {
+				v.fft.Mapping = cm
+			}
+		}
+		log.Debug().Str("mapping", v.mappings.Value).Msg("fft colour mapping changed")
+	}
+	if v.descriptors.Changed() {
+		for _, d := range audio.Descriptors {
+			if d.String() == v.descriptors.Value {
+				v.fft.Descriptor = d
+			}
+		}
+		log.Debug().Str("descriptor", v.descriptors.Value).Msg("fft descriptor changed")
+	}
+	if v.scales.Changed() {
+		for _, fs := range audio.FrequencyScales {
+			if fs.String() == v.scales.Value {
+				v.fft.Scale = fs
+			}
+		}
+		log.Debug().Str("scale", v.scales.Value).Msg("fft frequency scale changed")
+	}
+	if v.minFreqSlider.Changed() || v.maxFreqSlider.Changed() {
+		v.fft.MinFreq = float64(v.minFreqSlider.Value)
+		v.fft.MaxFreq = float64(v.maxFreqSlider.Value)
+		log.Debug().Float64("min", v.fft.MinFreq).Float64("max", v.fft.MaxFreq).Msg("fft frequency range changed")
+	}
+	if v.curveEditor.Changed() {
+		// The fft gets its own copy since the editor moves the points without locking it
+		v.fft.Curve = append(audio.Curve(nil), *v.curve...)
+		log.Debug().Int("points", len(*v.curve)).Msg("fft frequency curve changed")
+	}
+	if v.routesEditor.Changed() {
+		// The fft gets its own copy since the editor changes the routes without locking it
+		v.fft.Routes = append(audio.Routes(nil), *v.routes...)
+		log.Debug().Int("routes", len(*v.routes)).Msg("fft colour routes changed")
+	}
+	if v.paletteCheckbox.Changed() {
+		if v.paletteCheckbox.Value {
+			v.fft.Palette = audio.ScriabinPalette()
+		} else {
+			v.fft.Palette = nil
+		}
+		log.Debug().Bool("value", v.paletteCheckbox.Value).Msg("fft palette toggled")
+	}
+	if v.beatModes.Changed() {
+		for _, bm := range audio.BeatModes {
+			if bm.String() == v.beatModes.Value {
+				v.fft.BeatMode = bm
+			}
+		}
+		log.Debug().Str("mode", v.beatModes.Value).Msg("fft beat mode changed")
 	}
 
 	// Options
-	if v.dampCheckbox.Changed() {
-		v.fft.Damp = v.dampCheckbox.Value
-		log.Debug().Bool("value", v.fft.Damp).Msg("fft damp toggled changed")
+	if v.windowsCombobox.HasSelected() && v.windowsCombobox.SelectedText() != v.currentWindow {
+		v.currentWindow = v.windowsCombobox.SelectedText()
+		for _, w := range audio.Windows {
+			if w.String() == v.currentWindow {
+				v.fft.Window = w
+			}
+		}
+		log.Debug().Str("window", v.currentWindow).Msg("fft window changed")
 	}
-	if v.dampSlider.Changed() {
-		v.fft.ChangeSampleRate(time.Duration(v.dampSlider.Value) * time.Millisecond)
-		log.Debug().Float32("value", v.dampSlider.Value).Msg("fft damp value changed")
+	if v.channelModes.Changed() {
+		for _, cm := range audio.ChannelModes {
+			if cm.String() == v.channelModes.Value {
+				v.fft.ChannelMode = cm
+			}
+		}
+		log.Debug().Str("mode", v.channelModes.Value).Msg("fft channel mode changed")
 	}
-	if v.dampReset.Clicked() {
-		v.dampSlider.Value = v.defaultDamp
-		v.fft.ChangeSampleRate(time.Duration(v.dampSlider.Value) * time.Millisecond)
-		log.Debug().Float32("value", v.dampSlider.Value).Msg("fft damp reset")
+	if v.pitchModes.Changed() {
+		for _, pm := range audio.PitchModes {
+			if pm.String() == v.pitchModes.Value {
+				v.fft.PitchMode = pm
+			}
+		}
+		log.Debug().Str("mode", v.pitchModes.Value).Msg("fft pitch mode changed")
+	}
+	if v.brightCheckbox.Changed() || v.satCheckbox.Changed() {
+		v.fft.Dynamics.Brightness = v.brightCheckbox.Value
+		v.fft.Dynamics.Saturation = v.satCheckbox.Value
+		log.Debug().Bool("brightness", v.brightCheckbox.Value).Bool("saturation", v.satCheckbox.Value).Msg("fft dynamics toggled")
+	}
+	if v.floorSlider.Changed() || v.ceilingSlider.Changed() || v.curveSlider.Changed() {
+		v.fft.Dynamics.Floor = float64(v.floorSlider.Value)
+		v.fft.Dynamics.Ceiling = float64(v.ceilingSlider.Value)
+		v.fft.Dynamics.Curve = float64(v.curveSlider.Value)
+		log.Debug().Interface("dynamics", v.fft.Dynamics).Msg("fft dynamics changed")
+	}
+	if v.adaptiveCheckbox.Changed() {
+		v.fft.Dynamics.Adaptive = v.adaptiveCheckbox.Value
+		log.Debug().Bool("value", v.adaptiveCheckbox.Value).Msg("fft adaptive dynamics toggled")
+	}
+	if v.attackSlider.Changed() || v.releaseSlider.Changed() {
+		v.fft.AGC.Attack = time.Duration(v.attackSlider.Value) * time.Millisecond
+		v.fft.AGC.Release = time.Duration(float64(v.releaseSlider.Value) * float64(time.Second))
+		log.Debug().Dur("attack", v.fft.AGC.Attack).Dur("release", v.fft.AGC.Release).Msg("fft agc changed")
+	}
+	if v.separateCheckbox.Changed() || v.pulseSlider.Changed() {
+		v.fft.Separation.Enabled = v.separateCheckbox.Value
+		v.fft.Separation.Pulse = float64(v.pulseSlider.Value)
+		log.Debug().Interface("separation", v.fft.Separation).Msg("fft separation changed")
+	}
+	if v.gateCheckbox.Changed() {
+		v.fft.Gate.Enabled = v.gateCheckbox.Value
+		log.Debug().Bool("value", v.gateCheckbox.Value).Msg("fft gate toggled")
+	}
+	if v.thresholdSlider.Changed() || v.holdSlider.Changed() {
+		v.fft.Gate.Threshold = float64(v.thresholdSlider.Value)
+		v.fft.Gate.Hold = time.Duration(float64(v.holdSlider.Value) * float64(time.Second))
+		log.Debug().Float64("threshold", v.fft.Gate.Threshold).Dur("hold", v.fft.Gate.Hold).Msg("fft gate changed")
+	}
+	if v.idleEffects.Changed() {
+		for _, ie := range audio.IdleEffects {
+			if ie.String() == v.idleEffects.Value {
+				v.fft.Gate.Effect = ie
+			}
+		}
+		log.Debug().Str("effect", v.idleEffects.Value).Msg("fft idle effect changed")
+	}
+	if v.tempoCheckbox.Changed() {
+		v.fft.TempoSync = v.tempoCheckbox.Value
+		log.Debug().Bool("value", v.fft.TempoSync).Msg("fft tempo sync toggled")
+	}
+	if v.smoothFilters.Changed() || v.smoothTargets.Changed() {
+		for _, sf := range audio.SmoothingFilters {
+			if sf.String() == v.smoothFilters.Value {
+				v.fft.Smoothing.Filter = sf
+			}
+		}
+		for _, st := range audio.SmoothingTargets {
+			if st.String() == v.smoothTargets.Value {
+				v.fft.Smoothing.Target = st
+			}
+		}
+		log.Debug().Str("filter", v.smoothFilters.Value).Str("target", v.smoothTargets.Value).Msg("fft smoothing filter changed")
+	}
+	if v.smoothTimeSlider.Changed() || v.cutoffSlider.Changed() || v.betaSlider.Changed() || v.rateSlider.Changed() {
+		v.fft.Smoothing.Time = time.Duration(v.smoothTimeSlider.Value) * time.Millisecond
+		v.fft.Smoothing.MinCutoff = float64(v.cutoffSlider.Value)
+		v.fft.Smoothing.Beta = float64(v.betaSlider.Value)
+		v.fft.Smoothing.Rate = float64(v.rateSlider.Value)
+		log.Debug().Interface("smoothing", v.fft.Smoothing).Msg("fft smoothing changed")
 	}
 }
 
@@ -261,19 +644,51 @@ func (v *Visualisation) startCapture() {
 		}
 	}
 
-	err := v.audio.StartCapture(device, v.fft, v.audioConfig)
-	if err != nil {
-		log.Error().Err(err).Str("device", name).Msg("failed to start capture")
+	v.StartSource(v.audio.NewCaptureSource(device, v.audioConfig))
+	log.Debug().Str("device", name).Msg("started capture")
+}
+
+// StartSource visualises the audio from src instead of from the selected
+// device, the source which is currently running is stopped
+func (v *Visualisation) StartSource(src audio.Source) {
+	if v.cancel != nil {
+		v.cancel()
 	}
+	ctx, cancel := context.WithCancel(context.Background())
+	v.cancel = cancel
 	v.started = true
-	log.Debug().Err(err).Str("device", name).Msg("started capture")
+
+	go func() {
+		err := v.audio.Run(ctx, src, v.fft)
+		if err != nil && err != context.Canceled {
+			log.Error().Err(err).Msg("audio source failed")
+		}
+		log.Debug().Err(err).Msg("audio source finished")
+	}()
 }
 
 func (v *Visualisation) stopCapture() {
-	go v.audio.StopCapture()
+	if v.cancel != nil {
+		v.cancel()
+		v.cancel = nil
+	}
 	// We get Frame events because we are clicking the button so no need to call invalidate,
 	// we only have to change the current colour
 	v.started = false
 	v.currentColour = colorful.Color{R: 0, G: 0, B: 0}
 	log.Debug().Msg("stopped capture")
 }
+
+// labelledSlider lays out a slider with a label to its right
+func labelledSlider(th *material.Theme, f *widget.Float, min, max float32, label string) layout.Widget {
+	return func(gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+			layout.Flexed(1, material.Slider(th, f, min, max).Layout),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return layout.UniformInset(unit.Dp(8)).Layout(gtx,
+					material.Body2(th, label).Layout,
+				)
+			}),
+		)
+	}
+}
@@ -0,0 +1,39 @@
+package gui
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+
+	"currents/internal/log"
+	"currents/pkg/audio"
+)
+
+func loadCurve() *audio.Curve {
+	curve := audio.DefaultCurve()
+
+	// Use the saved curve if one exists
+	f, err := os.OpenFile("curve.json", os.O_CREATE|os.O_RDONLY, 0644)
+	if err != nil {
+		log.Fatal().Err(err).Msg("could not open curve.json")
+	}
+	defer f.Close()
+
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		log.Fatal().Err(err).Msg("could not read curve.json")
+	}
+
+	if len(data) > 0 {
+		var customCurve audio.Curve
+		if err = json.Unmarshal(data, &customCurve); err != nil {
+			log.Warn().Err(err).Msg("ignoring curve.json")
+		} else if err = customCurve.Validate(); err != nil {
+			log.Warn().Err(err).Msg("ignoring curve.json")
+		} else {
+			curve = customCurve
+		}
+	}
+
+	return &curve
+}
@@ -13,7 +13,7 @@ import (
 	"currents/pkg/audio"
 )
 
-func loop(w *app.Window, drawLayout layout.Widget, gradients *audio.Gradients) error {
+func loop(w *app.Window, drawLayout layout.Widget, gradients *audio.Gradients, curve *audio.Curve, routes *audio.Routes) error {
 	var ops op.Ops
 
 	for {
@@ -36,6 +36,28 @@ func loop(w *app.Window, drawLayout layout.Widget, gradients *audio.Gradients) e
 				log.Error().Err(err).Msg("failed to save to gradients.json")
 			}
 
+			// Save the frequency curve on exit
+			data, err = json.MarshalIndent(curve, "", "    ")
+			if err != nil {
+				log.Error().Err(err).Msg("failed to marshal curve")
+			}
+
+			err = os.WriteFile("curve.json", data, 0644)
+			if err != nil {
+				log.Error().Err(err).Msg("failed to save to curve.json")
+			}
+
+			// Save the colour routes on exit
+			data, err = json.MarshalIndent(routes, "", "    ")
+			if err != nil {
+				log.Error().Err(err).Msg("failed to marshal routes")
+			}
+
+			err = os.WriteFile("routes.json", data, 0644)
+			if err != nil {
+				log.Error().Err(err).Msg("failed to save to routes.json")
+			}
+
 			return e.Err
 		}
 	}
@@ -0,0 +1,39 @@
+package gui
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+
+	"currents/internal/log"
+	"currents/pkg/audio"
+)
+
+func loadRoutes() *audio.Routes {
+	routes := audio.DefaultRoutes()
+
+	// Use the saved routes if they exist
+	f, err := os.OpenFile("routes.json", os.O_CREATE|os.O_RDONLY, 0644)
+	if err != nil {
+		log.Fatal().Err(err).Msg("could not open routes.json")
+	}
+	defer f.Close()
+
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		log.Fatal().Err(err).Msg("could not read routes.json")
+	}
+
+	if len(data) > 0 {
+		// Unknown features and parameters are only warned about
+		// since they may be from a different version
+		var customRoutes audio.Routes
+		if err = json.Unmarshal(data, &customRoutes); err != nil {
+			log.Warn().Err(err).Msg("ignoring routes.json")
+		} else {
+			routes = customRoutes
+		}
+	}
+
+	return &routes
+}
@@ -19,14 +19,16 @@ func Run() {
 	server := session.NewServer()
 	th := material.NewTheme(gofont.Collection())
 	gradients := loadGradients()
+	curve := loadCurve()
+	routes := loadRoutes()
 
 	// Create the tabs
-	tabs := createTabs(th, w, gradients, server)
+	tabs := createTabs(th, w, gradients, curve, routes, server)
 	drawFunc := tabs.Layout(th)
 
 	go func() {
 		// Run the event loop until finish/error
-		err := loop(w, drawFunc, gradients)
+		err := loop(w, drawFunc, gradients, curve, routes)
 
 		// Always try to close the connection to the arduino
 		arduinoErr := server.Disconnect()
@@ -10,11 +10,11 @@ import (
 	"currents/pkg/session"
 )
 
-func createTabs(th *material.Theme, w *app.Window, gradients *audio.Gradients, server *session.Server) simple.Tabs {
+func createTabs(th *material.Theme, w *app.Window, gradients *audio.Gradients, curve *audio.Curve, routes *audio.Routes, server *session.Server) simple.Tabs {
 	drawMode := audio.Blended
 	// Redrawing happens outside a frame event so we need to call
 	// window.Invalidate instead of using op.InvalidateOp
-	v := complex.NewVisualisation(gradients, func() { w.Invalidate() }, &drawMode, server)
+	v := complex.NewVisualisation(gradients, curve, routes, func() { w.Invalidate() }, &drawMode, server)
 	ge := complex.NewGradientEditor(gradients, v.GradientsCombobox(), &drawMode)
 	ac := complex.NewArduinoController(server)
 
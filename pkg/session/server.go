@@ -58,9 +58,28 @@ func (s *Server) Disconnect() error {
 	return s.port.Close()
 }
 
+// Which part of the strip a colour is shown on, it's sent in
+// the unused top byte of the packed colour
+const (
+	wholeStrip = iota
+	firstHalf
+	secondHalf
+)
+
 func (s *Server) SendColour(clr colorful.Color) {
+	s.send(clr, wholeStrip)
+}
+
+// SendColours shows the first colour on the first half of the strip and
+// the second colour on the second half
+func (s *Server) SendColours(first, second colorful.Color) {
+	s.send(first, firstHalf)
+	s.send(second, secondHalf)
+}
+
+func (s *Server) send(clr colorful.Color, segment uint32) {
 	buf := make([]byte, 8)
-	binary.BigEndian.PutUint64(buf, markColour(packColour(clr)))
+	binary.BigEndian.PutUint64(buf, markColour(segment<<24|packColour(clr)))
 	s.port.Write(buf)
 }
 
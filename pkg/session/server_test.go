@@ -26,3 +26,8 @@ func TestPackColour(t *testing.T) {
 	assert.Equal(t, "00000000000000001111111100000000", showBinary(packColour(green)))
 	assert.Equal(t, "00000000000000000000000011111111", showBinary(packColour(blue)))
 }
+
+func TestMarkColour(t *testing.T) {
+	assert.Equal(t, uint64(0xAABB00FF0000CCDD), markColour(packColour(red)))
+	assert.Equal(t, uint64(0xAABB020000FFCCDD), markColour(secondHalf<<24|packColour(blue)))
+}